@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/chandler37/gobackgammon/analysis"
 	"github.com/chandler37/gobackgammon/brd"
 )
 
@@ -25,6 +26,29 @@ func BenchmarkPlayGameConservativeReproduciblySeeded(b *testing.B) {
 	helpBenchmarkPlayGameConservative(b)
 }
 
+// A choice in the opening with foresight. MakePlayerConservative's doc
+// comment quotes these.
+func BenchmarkMakePlayerConservative2Ply(b *testing.B) {
+	helpBenchmarkMakePlayerConservative(b, 2)
+}
+
+func BenchmarkMakePlayerConservative3Ply(b *testing.B) {
+	helpBenchmarkMakePlayerConservative(b, 3)
+}
+
+func helpBenchmarkMakePlayerConservative(b *testing.B, plies uint64) {
+	rand.Seed(37)
+	board := brd.New(true)
+	board.Roller = Red
+	board.Roll = brd.Roll{5, 4}
+	choices := board.LegalContinuations()
+	chooser := MakePlayerConservative(plies, nil)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		chooser(choices)
+	}
+}
+
 func helpBenchmarkPlayGameConservative(b *testing.B) {
 	numBoards, numRedWins, numWhiteWins, numBackgammons := 0, 0, 0, 0
 	for n := 0; n < b.N; n++ {
@@ -63,11 +87,11 @@ func TestAiPlayGame(t *testing.T) {
 	examples := [...]example{
 		example{
 			1337,
-			Red,
+			White,
 			1,
-			"Score{Goal:0,W:0,r:1,Crawford on,inactive}",
-			45,
-			"{r to play   33 after playing   33; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:W, 14 W off, 15 r off, Score{Goal:0,W:0,r:1,Crawford on,inactive}}",
+			"Score{Goal:0,W:1,r:0,Crawford inactive}",
+			44,
+			"{W to play    2 after playing    5; !dbl; 1:rr 2:rrrrrrr 3:rrr 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, 15 W off, 3 r off, Score{Goal:0,W:1,r:0,Crawford inactive}}",
 			playerConservative,
 			func(state interface{}, b *brd.Board) {
 				if iv := b.Invalidity(brd.IgnoreRollValidity); iv != "" {
@@ -83,9 +107,9 @@ func TestAiPlayGame(t *testing.T) {
 			1338,
 			Red,
 			1,
-			"Score{Goal:0,W:0,r:1,Crawford on,inactive}",
-			62,
-			"{r after playing   42; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:WWW, 12 W off, 15 r off, Score{Goal:0,W:0,r:1,Crawford on,inactive}}",
+			"Score{Goal:0,W:0,r:1,Crawford inactive}",
+			44,
+			"{r after playing   53; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:WWWWWWW, 8 W off, 15 r off, Score{Goal:0,W:0,r:1,Crawford inactive}}",
 			playerConservative,
 			func(state interface{}, b *brd.Board) {
 				if iv := b.Invalidity(brd.IgnoreRollValidity); iv != "" {
@@ -160,7 +184,7 @@ func TestPlayerConservative(t *testing.T) {
 				b.Roller = Red
 				b.Roll = brd.Roll{5, 4}
 			},
-			"{r after playing   54; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrrr 9: 10: 11: 12:WWWWW 13:rrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20:r 21: 22: 23: 24:r}",
+			"{r after playing   54; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrrr 9:r 10: 11: 12:WWWWW 13:rrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr}",
 			nil},
 		example{
 			func(b *brd.Board) {
@@ -198,7 +222,7 @@ func TestPlayerConservative(t *testing.T) {
 				b.Pips[brd.BorneOffWhitePip].Reset(8, White)
 				b.MatchScore.Goal = 1
 			},
-			"{r after playing   51; !dbl; 1:rrrrrr 2:rrrrrr 3:r 4: 5: 6: 7: 8: 9:r 10: 11: 12: 13: 14: 15: 16: 17: 18:r 19: 20: 21: 22: 23:WWWWWWW 24:, 8 W off, Score{Goal:1,W:0,r:0,Crawford on,inactive}}",
+			"{r after playing   51; !dbl; 1:rrrrrr 2:rrrrrr 3:r 4: 5: 6: 7: 8: 9:r 10: 11: 12: 13: 14: 15: 16: 17: 18:r 19: 20: 21: 22: 23:WWWWWWW 24:, 8 W off, Score{Goal:1,W:0,r:0,Crawford inactive}}",
 			nil},
 		example{
 			func(b *brd.Board) {
@@ -225,12 +249,9 @@ func TestPlayerConservative(t *testing.T) {
 		example{
 			func(b *brd.Board) {
 				// {r to play   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off}
-
-				// TODO(chandler37): Add a new heuristic so that {r after
-				// playing 41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8: 9: 10:
-				// 11: 12:r 13:rrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW
-				// 23:WWW 24:WW, 4 W off} is the chosen move because the pip
-				// count outside of home is most reduced.
+				//
+				// The pip count outside of home is most reduced by 17=>12, but
+				// 17=>13 2=>1 has the better race equity.
 				b.Roller = Red
 				b.Roll = brd.Roll{4, 1}
 				b.Pips = brd.Points28{}
@@ -247,35 +268,35 @@ func TestPlayerConservative(t *testing.T) {
 				b.Pips[24].Reset(2, White)
 				b.Pips[brd.BorneOffWhitePip].Reset(4, White)
 			},
-			"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rrr 6:r 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off}",
+			"{r after playing   41; !dbl; 1:r 2:rr 3:rrr 4:r 5:rr 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off}",
 			[]string{
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rrr 6:r 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=11 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=13 minProbabilityOfGettingBackgammoned=0 randomizer=8734748956570804315)",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:rr 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by randomizer (2112854853711373400))",
-				"{r after playing   41; !dbl; 1: 2:rrrr 3:rr 4:r 5:rr 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by randomizer (3543339188040204842))",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8: 9: 10: 11: 12:r 13:rrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by randomizer (4753501743317724670))",
-				"{r after playing   41; !dbl; 1:r 2:rr 3:rrr 4:r 5:rr 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by randomizer (5999264223995105160))",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrrr 4: 5:rr 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by randomizer (8530525039392881481))",
-				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrr 4:r 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16:r 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (16))",
-				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrr 4:r 5:rr 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16:r 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (16))",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16:r 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (16))",
-				"{r after playing   41; !dbl; 1:rr 2:rr 3:rrr 4:r 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1:r 2:rrrr 3:rr 4:r 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrrr 4: 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrr 4:rr 5: 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrr 4:r 5:rr 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrr 4:r 5:r 6:rr 7: 8: 9: 10: 11: 12:r 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrrrr 3:rr 4:r 5:rr 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrrr 4: 5:rr 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrr 4:rr 5:r 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrr 4:r 5:rrr 6: 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrr 4:r 5:rr 6:r 7: 8: 9: 10: 11: 12:r 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
+				"{r after playing   41; !dbl; 1:r 2:rr 3:rrr 4:r 5:rr 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=11 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=13 minMyExpectedRollsToBearOff=0 minMyPipsOutsideHome=28 minMyRaceEquityRank=0 randomizer=5999264223995105160)",
 				"{r after playing   41; !dbl; 1:r 2:rr 3:rrr 4:r 5:rr 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrrr 3:rr 4:r 5:rr 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrrr 4: 5:rr 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:rr 5:r 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rrr 6:r 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8:r 9: 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
-				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8: 9:r 10: 11: 12:r 13:r 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minHowFarAwayMyFarthestIs (17))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8:r 9: 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (2))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8: 9:r 10: 11: 12:r 13:r 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (2))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16:r 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (2))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rr 6:rr 7: 8: 9: 10: 11: 12:r 13:rrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (2))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:rr 5:r 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (6))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rrr 6:r 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (6))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:rr 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (6))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrr 4:r 5:rrr 6:r 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (6))",
+				"{r after playing   41; !dbl; 1: 2:rrrr 3:rr 4:r 5:rr 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (10))",
+				"{r after playing   41; !dbl; 1: 2:rrrr 3:rr 4:r 5:rr 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (10))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrrr 4: 5:rr 6:rr 7: 8: 9:r 10: 11: 12: 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (12))",
+				"{r after playing   41; !dbl; 1: 2:rrr 3:rrrr 4: 5:rr 6:rr 7: 8: 9: 10: 11: 12: 13:rrrr 14: 15: 16: 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (12))",
+				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrr 4:r 5:r 6:rr 7: 8: 9: 10: 11: 12:r 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (14))",
+				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrr 4:r 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16:r 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (14))",
+				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrr 4:rr 5: 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (16))",
+				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrr 4:r 5:rr 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (16))",
+				"{r after playing   41; !dbl; 1:rr 2:rr 3:rrr 4:r 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (18))",
+				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrr 4:r 5:rr 6:r 7: 8: 9: 10: 11: 12:r 13:rr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (19))",
+				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrr 4:r 5:rr 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16:r 17: 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (19))",
+				"{r after playing   41; !dbl; 1:r 2:rrrr 3:rr 4:r 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (21))",
+				"{r after playing   41; !dbl; 1:r 2:rrr 3:rrrr 4: 5:r 6:rr 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (22))",
+				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrr 4:rr 5:r 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (23))",
+				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrr 4:r 5:rrr 6: 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (23))",
+				"{r after playing   41; !dbl; 1: 2:rrrrr 3:rr 4:r 5:rr 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (25))",
+				"{r after playing   41; !dbl; 1: 2:rrrr 3:rrrr 4: 5:rr 6:r 7: 8: 9: 10: 11: 12: 13:rrr 14: 15: 16: 17:r 18: 19: 20:WW 21: 22:WWWW 23:WWW 24:WW, 4 W off} (Ruled out by minMyRaceEquityRank (26))",
 			}},
 	}
 	for exNum, ex := range examples {
@@ -324,14 +345,68 @@ func TestMakePlayerConservative(t *testing.T) {
 			panic("i will not be called")
 		})
 	analyzedChoices := chooser(choices)
-	if cs := analyzedChoices[0].Board.String(); cs != "{r after playing   54; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrrr 9: 10: 11: 12:WWWWW 13:rrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20:r 21: 22: 23: 24:r}" {
+	if cs := analyzedChoices[0].Board.String(); cs != "{r after playing   54; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrrr 9:r 10: 11: 12:WWWWW 13:rrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr}" {
 		t.Errorf("choice (starting from %v)\nwas %v\nfrom\n%v", b.String(), cs, prettyChoices(choices))
 	}
 	chooser = MakePlayerConservative(0, nil)
 	analyzedChoices = chooser(choices)
-	if cs := analyzedChoices[0].Board.String(); cs != "{r after playing   54; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrrr 9: 10: 11: 12:WWWWW 13:rrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20:r 21: 22: 23: 24:r}" {
+	if cs := analyzedChoices[0].Board.String(); cs != "{r after playing   54; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrrr 9:r 10: 11: 12:WWWWW 13:rrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr}" {
 		t.Errorf("choice (starting from %v)\nwas %v\nfrom\n%v", b.String(), cs, prettyChoices(choices))
 	}
+
+	// With foresight, the opponent's replies come from otherPlayer.
+	rand.Seed(37)
+	numReplies := 0
+	otherPlayer := func(choices []*brd.Board) []brd.AnalyzedBoard {
+		numReplies++
+		return playerConservative(choices)
+	}
+	analyzedChoices = MakePlayerConservative(1, otherPlayer)(choices)
+	if len(analyzedChoices) != len(choices) {
+		t.Fatalf("analyzedChoices=\n%v", prettyAnalyzedChoices(analyzedChoices))
+	}
+	if x := analyzedChoices[0].String(); x != "{r after playing   54; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrrr 9: 10: 11: 12:WWWWW 13:rrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20:r 21: 22: 23: 24:r} (1-ply equity +0.135: win 56.8% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%))" {
+		t.Errorf("1-ply choice was %v from\n%v", x, prettyAnalyzedChoices(analyzedChoices))
+	}
+	// ConservativeEvaluator, not the heuristics of the 0-ply player, ranks
+	// the moves, so the choice differs: we move a back checker up rather
+	// than bring two down from the midpoint.
+	//
+	// Four candidates survive the first move filter, and each faces the
+	// opponent's 21 rolls, which is all that 1-ply sees.
+	if numReplies != 4*21 {
+		t.Errorf("numReplies=%d", numReplies)
+	}
+	// 2-ply adds our 21 rolls after each of the opponent's, which we play
+	// ourselves, so the opponent replies only at the first ply: 4*21 times
+	// for the 1-ply pass and 3*21 more for the three candidates that survive
+	// the second move filter.
+	numReplies = 0
+	analyzedChoices = MakePlayerConservative(2, otherPlayer)(choices)
+	if x := analyzedChoices[0].String(); x != "{r after playing   54; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrrr 9: 10: 11: 12:WWWWW 13:rrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20:r 21: 22: 23: 24:r} (2-ply equity +0.132: win 56.6% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%))" {
+		t.Errorf("2-ply choice was %v from\n%v", x, prettyAnalyzedChoices(analyzedChoices))
+	}
+	if numReplies != (4+3)*21 {
+		t.Errorf("numReplies=%d", numReplies)
+	}
+	// The move filters leave only the best candidates for the deeper search.
+	plies := []string{}
+	for _, a := range analyzedChoices {
+		plies = append(plies, fmt.Sprint(a.Analysis.(Evaluation).Plies))
+	}
+	if x := strings.Join(plies, " "); x != "2 2 2 1 0 0 0 0 0" {
+		t.Errorf("plies=%v", x)
+	}
+	// A game that is over needs no evaluation.
+	b.Pips = brd.Points28{}
+	b.Pips[2].Reset(1, Red)
+	b.Pips[brd.BorneOffRedPip].Reset(14, Red)
+	b.Pips[19].Reset(15, White)
+	b.Roll = brd.Roll{2, 1}
+	analyzedChoices = MakePlayerConservative(1, nil)(b.LegalContinuations())
+	if x := analyzedChoices[0].Analysis.Summary(); x != "1-ply equity +2.000: win 100.0% (gammon 100.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("summary=%v", x)
+	}
 }
 
 func TestPlayerRandom(t *testing.T) {
@@ -371,12 +446,12 @@ func TestPlayerRacer(t *testing.T) {
 				b.Pips[24].Reset(6, White)
 				b.Pips[brd.BorneOffWhitePip].Reset(9, White)
 			},
-			"{r after playing   51; !dbl; 1:rr 2:rrrrr 3:rrrr 4: 5: 6:rrr 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:WWWWWW, 9 W off, 1 r off} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=1 minHowFarAwayMyFarthestIs=6 minProbabilityOfGettingBackgammoned=0 randomizer=1926012586526624009)"},
+			"{r after playing   51; !dbl; 1:rr 2:rrrrr 3:rrrr 4: 5: 6:rrr 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:WWWWWW, 9 W off, 1 r off} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=1 minHowFarAwayMyFarthestIs=6 minMyExpectedRollsToBearOff=7415393 minMyPipsOutsideHome=0 minMyRaceEquityRank=0 randomizer=1926012586526624009)"},
 
 		example{
 			func(b *brd.Board) {
 			},
-			"{r after playing   63; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrr 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18:r 19:WWWWW 20: 21:r 22: 23: 24:} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=5 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=21 minProbabilityOfGettingBackgammoned=3 randomizer=1926012586526624009)"},
+			"{r after playing   63; !dbl; 1:WW 2:r 3: 4: 5:r 6:rrrrr 7: 8:r 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=7 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=24 minMyExpectedRollsToBearOff=0 minMyPipsOutsideHome=73 minMyRaceEquityRank=0 randomizer=1926012586526624009)"},
 
 		example{
 			func(b *brd.Board) {
@@ -388,7 +463,7 @@ func TestPlayerRacer(t *testing.T) {
 				b.Pips[8].Reset(1, Red)
 				b.Pips[11].Reset(1, Red)
 			},
-			"{r after playing   21; !dbl; 1: 2: 3: 4: 5: 6:rrrrrrrrrrrrrr 7: 8: 9: 10:r 11: 12: 13: 14: 15: 16: 17: 18: 19:WWWWWWWWWWWWWWW 20: 21: 22: 23: 24:} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=10 minProbabilityOfGettingBackgammoned=0 randomizer=1926012586526624009)"},
+			"{r after playing   21; !dbl; 1: 2: 3: 4: 5:r 6:rrrrrrrrrrrrr 7: 8: 9: 10: 11:r 12: 13: 14: 15: 16: 17: 18: 19:WWWWWWWWWWWWWWW 20: 21: 22: 23: 24:} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=11 minMyExpectedRollsToBearOff=0 minMyPipsOutsideHome=5 minMyRaceEquityRank=0 randomizer=1926012586526624009)"},
 
 		example{
 			func(b *brd.Board) {
@@ -400,10 +475,9 @@ func TestPlayerRacer(t *testing.T) {
 				b.Pips[7].Reset(2, Red)
 				b.Pips[2].Reset(12, Red)
 			},
-			"{r after playing   61; !dbl; 1:r 2:rrrrrrrrrrrr 3: 4: 5: 6:r 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17:r 18: 19:WWWWWWWWWWWWWWW 20: 21: 22: 23: 24:} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=17 minProbabilityOfGettingBackgammoned=0 randomizer=1926012586526624009)",
-			// TODO(chandler37): Make PlayerRacer prefer this move:
-			//
-			// "{r after playing   61; !dbl; 1: 2:rrrrrrrrrrrr 3: 4: 5: 6:r 7:r 8: 9: 10: 11:r 12: 13: 14: 15: 16: 17: 18: 19:WWWWWWWWWWWWWWW 20: 21: 22: 23: 24:}"},
+			"{r after playing   61; !dbl; 1: 2:rrrrrrrrrrrr 3: 4: 5: 6:r 7:r 8: 9: 10: 11:r 12: 13: 14: 15: 16: 17: 18: 19:WWWWWWWWWWWWWWW 20: 21: 22: 23: 24:} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=13 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=11 minMyExpectedRollsToBearOff=0 minMyPipsOutsideHome=6 minMyRaceEquityRank=0 randomizer=1926012586526624009)",
+			// not 7=>1 7=>6, which brings one more checker home but wastes five
+			// pips.
 		},
 
 		example{
@@ -416,7 +490,7 @@ func TestPlayerRacer(t *testing.T) {
 				b.Pips[6].Reset(2, Red)
 				b.Pips[2].Reset(12, Red)
 			},
-			"{r after playing   61; !dbl; 1: 2:rrrrrrrrrrrr 3: 4: 5: 6:rr 7: 8: 9: 10:r 11: 12: 13: 14: 15: 16: 17: 18: 19:WWWWWWWWWWWWWWW 20: 21: 22: 23: 24:} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=10 minProbabilityOfGettingBackgammoned=0 randomizer=1926012586526624009)",
+			"{r after playing   61; !dbl; 1:r 2:rrrrrrrrrrr 3: 4: 5: 6:rr 7: 8: 9: 10: 11:r 12: 13: 14: 15: 16: 17: 18: 19:WWWWWWWWWWWWWWW 20: 21: 22: 23: 24:} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=11 minMyExpectedRollsToBearOff=0 minMyPipsOutsideHome=5 minMyRaceEquityRank=0 randomizer=1926012586526624009)",
 		},
 
 		example{
@@ -429,7 +503,7 @@ func TestPlayerRacer(t *testing.T) {
 				b.Pips[19].Reset(2, White)
 				b.Pips[23].Reset(12, White)
 			},
-			"{W after playing   61; !dbl; 1: 2: 3: 4: 5: 6:rrrrrrrrrrrrrrr 7: 8: 9: 10: 11: 12: 13: 14:W 15: 16: 17: 18: 19:WW 20: 21: 22: 23:WWWWWWWWWWWW 24:} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=11 minProbabilityOfGettingBackgammoned=0 randomizer=1926012586526624009)",
+			"{W after playing   61; !dbl; 1: 2: 3: 4: 5: 6:rrrrrrrrrrrrrrr 7: 8: 9: 10: 11: 12: 13:W 14: 15: 16: 17: 18: 19:WW 20: 21: 22: 23:WWWWWWWWWWW 24:W} (wasn't ruled out by heuristics. Details: maxMyCheckersAtHome=14 maxMyCheckersBorneOff=0 minHowFarAwayMyFarthestIs=12 minMyExpectedRollsToBearOff=0 minMyPipsOutsideHome=6 minMyRaceEquityRank=0 randomizer=1926012586526624009)",
 		},
 	}
 	for exNum, ex := range examples {
@@ -511,3 +585,92 @@ func TestMinmaximizerAndConverter(t *testing.T) {
 		}
 	}
 }
+
+func TestPlayerConservativeChoosesAceyDeuceyBonus(t *testing.T) {
+	rand.Seed(37)
+	b := brd.NewVariant(brd.AceyDeucey, true)
+	b.Roller = White
+	b.Roll = brd.Roll{2, 1}
+	b.ToEnter = [3]uint8{}
+	b.Pips[19].Reset(15, White)
+	b.Pips[6].Reset(15, Red)
+	choices := b.LegalContinuations()
+	analyzedChoices := MakePlayerConservative(0, nil)(choices)
+	if len(analyzedChoices) != len(choices) {
+		t.Fatalf("analyzedChoices=\n%v", prettyAnalyzedChoices(analyzedChoices))
+	}
+	// Only <6 6 6 6> bears off four.
+	best := analyzedChoices[0]
+	if best.Board.Bonus != 6 || !strings.Contains(best.Analysis.Summary(), ", 4 W off") {
+		t.Errorf("best=%v from\n%v", best, prettyAnalyzedChoices(analyzedChoices))
+	}
+}
+
+func TestChooseBonusKeepsEveryChoiceWhenTheTwoOneWins(t *testing.T) {
+	b := brd.NewVariant(brd.AceyDeucey, true)
+	b.Roller = White
+	b.Roll = brd.Roll{2, 1}
+	b.ToEnter = [3]uint8{}
+	b.Pips[brd.BorneOffWhitePip].Reset(13, White)
+	b.Pips[23].Reset(1, White)
+	b.Pips[24].Reset(1, White)
+	b.Pips[6].Reset(15, Red)
+	for name, chooser := range map[string]brd.Chooser{
+		"conservative": MakePlayerConservative(0, nil),
+		"evaluator":    ChooserFromEvaluator(RaceEvaluator),
+	} {
+		rand.Seed(37)
+		choices := b.LegalContinuations()
+		analyzed := chooser(choices)
+		if len(analyzed) != len(choices) {
+			t.Fatalf("%s: analyzed=\n%v", name, prettyAnalyzedChoices(analyzed))
+		}
+		if victor, _ := analyzed[0].Board.Victor(); victor != White {
+			t.Errorf("%s: analyzed=\n%v", name, prettyAnalyzedChoices(analyzed))
+		}
+		if x := analyzed[0].Analysis.Summary(); x != "equity +2.000: win 100.0% (gammon 100.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+			t.Errorf("%s: %v", name, x)
+		}
+		// Playing the one first leaves a checker behind.
+		if victor, _ := analyzed[len(analyzed)-1].Board.Victor(); victor != brd.NoChecker {
+			t.Errorf("%s: analyzed=\n%v", name, prettyAnalyzedChoices(analyzed))
+		}
+	}
+}
+
+func TestChooserFromEvaluator(t *testing.T) {
+	b := brd.New(true)
+	b.Roller = Red
+	b.Pips = brd.Points28{}
+	b.Roll = brd.Roll{6, 1}
+	b.Pips[19].Reset(15, White)
+	b.Pips[17].Reset(1, Red)
+	b.Pips[7].Reset(2, Red)
+	b.Pips[2].Reset(12, Red)
+	choices := b.LegalContinuations()
+	analyzed := ChooserFromEvaluator(RaceEvaluator)(choices)
+	if len(analyzed) != len(choices) {
+		t.Fatalf("analyzed=\n%v", prettyAnalyzedChoices(analyzed))
+	}
+	if x := analyzed[0].String(); x != "{r after playing   61; !dbl; 1: 2:rrrrrrrrrrrr 3: 4: 5: 6:r 7:r 8: 9: 10: 11:r 12: 13: 14: 15: 16: 17: 18: 19:WWWWWWWWWWWWWWW 20: 21: 22: 23: 24:} (equity +0.762: win 88.1% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%))" {
+		t.Errorf("choice was %v from\n%v", x, prettyAnalyzedChoices(analyzed))
+	}
+	for i := 1; i < len(analyzed); i++ {
+		if analyzed[i-1].Analysis.(Evaluation).Equity < analyzed[i].Analysis.(Evaluation).Equity {
+			t.Errorf("out of order:\n%v", prettyAnalyzedChoices(analyzed))
+		}
+	}
+
+	// Any Evaluator can play a game, even one that only counts pips.
+	pipCounter := EvaluatorFunc(func(b *brd.Board) analysis.Outcomes {
+		mine, theirs := b.PipCount(b.Roller), b.PipCount(b.Roller.OtherColor())
+		return analysis.Outcomes{Win: float64(theirs) / float64(mine+theirs)}
+	})
+	rand.Seed(37)
+	numBoards := 0
+	logger := func(_ interface{}, _ *brd.Board) { numBoards++ }
+	victor, stakes, _ := brd.New(true).PlayGame(nil, ChooserFromEvaluator(pipCounter), logger, nil, nil)
+	if x := fmt.Sprintf("%v %d %d", victor, stakes, numBoards); x != "r 2 507" {
+		t.Errorf("victor stakes numBoards=%v", x)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/chandler37/gobackgammon/analysis"
 	"github.com/chandler37/gobackgammon/brd"
 )
 
@@ -187,3 +188,60 @@ func probabilityOfGettingBackgammoned(b *brd.Board) (score int64) {
 	}
 	return
 }
+
+type bonusAnalysis struct {
+	Followup brd.AnalyzedBoard
+}
+
+func (b bonusAnalysis) Summary() string {
+	return fmt.Sprintf("followed by %v", b.Followup)
+}
+
+// In brd.AceyDeucey, playing <2 1> earns a doublet of your choice (see
+// brd.Board.Bonus). We judge each choice by how well chooser can play the
+// doublet that follows it. A choice whose <2 1> wins the game outright makes
+// the Bonus moot, so we judge it by its final equity instead, which outranks
+// any followup whose Analysis isn't an Evaluation.
+func chooseBonus(chooser brd.Chooser, choices []*brd.Board) []brd.AnalyzedBoard {
+	var winners []brd.AnalyzedBoard
+	followups := make([]*brd.Board, 0, len(choices))
+	original := make(map[*brd.Board]*brd.Board, len(choices))
+	for _, c := range choices {
+		if o, over := analysis.FinalOutcomes(c); over {
+			e := Evaluation{Outcomes: o, Equity: o.Equity(c, c.Roller)}
+			winners = append(winners, brd.AnalyzedBoard{Board: c, Analysis: e})
+			continue
+		}
+		next := *c
+		next.TakeTurn(nil, nil)
+		candidates := next.LegalContinuations()
+		followup := candidates[0]
+		if analyzed := chooser(candidates); len(analyzed) > 0 {
+			followup = analyzed[0].Board
+		}
+		brd.OptionallyReturnBoardsToPool(candidates, followup)
+		followups = append(followups, followup)
+		original[followup] = c
+	}
+	sort.SliceStable(winners, func(i, j int) bool {
+		return winners[i].Analysis.(Evaluation).Equity > winners[j].Analysis.(Evaluation).Equity
+	})
+	var ranked []brd.AnalyzedBoard
+	if len(followups) > 0 {
+		if ranked = chooser(followups); len(ranked) == 0 {
+			ranked = converter(followups[:1])
+		}
+	}
+	result := make([]brd.AnalyzedBoard, 0, len(winners)+len(ranked))
+	for _, r := range ranked {
+		for len(winners) > 0 {
+			if e, ok := r.Analysis.(Evaluation); ok && e.Equity > winners[0].Analysis.(Evaluation).Equity {
+				break
+			}
+			result = append(result, winners[0])
+			winners = winners[1:]
+		}
+		result = append(result, brd.AnalyzedBoard{Board: original[r.Board], Analysis: bonusAnalysis{r}})
+	}
+	return append(result, winners...)
+}
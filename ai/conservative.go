@@ -3,6 +3,7 @@ package ai
 import (
 	"fmt"
 
+	"github.com/chandler37/gobackgammon/analysis"
 	"github.com/chandler37/gobackgammon/brd"
 )
 
@@ -15,16 +16,25 @@ import (
 // hittable blots, and prefers not to leave even unhittable blots. (A blot is a
 // point containing only one Checker.)
 //
-// It detects if it's a race and plays differently then, delegating to
-// PlayerRacer.
+// It switches strategy by the kind of game, per analysis.Classify(): once
+// every choice is a race or a bear-off, it delegates to PlayerRacer.
 //
 // It uses math/rand.Intn to choose when the heuristics leave more than one choice.
 //
-// If amountOfForesight == 0, returns a chooser that never performs Monte-Carlo
-// simulations to help determine the best move. Otherwise, we will simulate
-// some number of rolls to help us choose. If otherPlayer is not provided, we
-// will use the result of MakePlayerConservative(0, nil). TODO(chandler37):
-// Implement the simulations.
+// If amountOfForesight == 0, returns a chooser that never looks ahead to help
+// determine the best move. Otherwise it is a different player: the heuristics
+// above no longer rank the moves, and ConservativeEvaluator, which hates
+// hittable blots in the same spirit, judges positions instead. It looks
+// amountOfForesight rolls (a.k.a. plies) ahead, counting the opponent's rolls
+// as well as ours: 1-ply averages ConservativeEvaluator's verdicts over the
+// opponent's 21 rolls, each played as otherPlayer would play it, and 2-ply
+// goes on to average over our 21 rolls after each of those, on which we play
+// to maximize our equity. To keep this fast we look deeply only at the few
+// candidates that look best when we don't look as far, much like gnubg's move
+// filters. In the opening, 2-ply takes about half a second, and 3-ply some
+// twenty-five times longer; see BenchmarkMakePlayerConservative2Ply. If
+// otherPlayer is not provided, we will use the result of
+// MakePlayerConservative(0, nil).
 //
 // TODO(chandler37): This does not avoid backgammons very well; see
 // TestPlayerConservative. That might be fine in a tournament depending on the
@@ -37,7 +47,7 @@ func MakePlayerConservative(amountOfForesight uint64, otherPlayer brd.Chooser) b
 	if otherPlayer == nil {
 		otherPlayer = MakePlayerConservative(0, nil)
 	}
-	panic("TODO(chandler37): implement me")
+	return makeForesightful(int(amountOfForesight), otherPlayer)
 }
 
 // A conservative Chooser with no foresight (0-ply).
@@ -48,13 +58,10 @@ func playerConservative(choices []*brd.Board) []brd.AnalyzedBoard {
 	if len(choices) == 1 {
 		return []brd.AnalyzedBoard{brd.AnalyzedBoard{Board: choices[0]}}
 	}
-	racing := true
-	for _, choice := range choices {
-		if !choice.Racing() {
-			racing = false
-		}
+	if choices[0].Bonus != brd.ZeroDie {
+		return chooseBonus(playerConservative, choices)
 	}
-	if racing {
+	if allRaces(choices) {
 		return PlayerRacer(choices)
 	}
 	nextRound := converter(choices)
@@ -62,7 +69,7 @@ func playerConservative(choices []*brd.Board) []brd.AnalyzedBoard {
 		"minMyBlotLiability",
 		nextRound,
 		func(b *brd.Board) int64 {
-			return int64(b.BlotLiability(b.Roller, false))
+			return int64(b.ShotLiability(b.Roller))
 		})
 	minimizer(
 		"minProbabilityOfGettingBackgammoned",
@@ -119,3 +126,13 @@ func playerConservative(choices []*brd.Board) []brd.AnalyzedBoard {
 	shuffle(nextRound)
 	return nextRound
 }
+
+// Whether analysis.Classify() finds every choice a race or a bear-off.
+func allRaces(choices []*brd.Board) bool {
+	for _, choice := range choices {
+		if !analysis.Classify(choice).Best().Class.IsRace() {
+			return false
+		}
+	}
+	return true
+}
@@ -4,6 +4,9 @@
 // You pass a brd.Chooser to brd.PlayGame(). You must seed math/rand
 // appropriately.
 //
+// To try out a new way of judging positions, implement an Evaluator and pass
+// it to ChooserFromEvaluator().
+//
 // TODO(chandler37): Implement an AI that chooses not just its continuation
 // brd.Board but also its brd.Roll. If you leave it a blot, it's very likely to
 // choose the next roll that hits it and makes a point on it. If you don't
@@ -0,0 +1,130 @@
+package ai
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// Judges a position for its Roller, i.e., the player who just moved, whose
+// opponent is on roll.
+type Evaluator interface {
+	Evaluate(b *brd.Board) analysis.Outcomes
+}
+
+// An Evaluator that judges many positions at once faster than one by one.
+type BatchEvaluator interface {
+	Evaluator
+	EvaluateBatch(boards []*brd.Board) []analysis.Outcomes
+}
+
+// An Evaluator that is just a function.
+type EvaluatorFunc func(*brd.Board) analysis.Outcomes
+
+func (f EvaluatorFunc) Evaluate(b *brd.Board) analysis.Outcomes {
+	return f(b)
+}
+
+// Evaluates a race per analysis.EstimateRace(). Don't use it if there's
+// contact, except as ConservativeEvaluator does.
+var RaceEvaluator Evaluator = EvaluatorFunc(func(b *brd.Board) analysis.Outcomes {
+	return analysis.EstimateRace(b, b.Roller.OtherColor()).Flip()
+})
+
+// A heuristic Evaluator for contact positions that, like
+// MakePlayerConservative(), hates hittable blots. It is RaceEvaluator's
+// verdict, except that, with the chance that the opponent hits at least one
+// of the Roller's blots, we assume it hits the one with the most shots,
+// sending it to the bar.
+//
+// With contact, RaceEvaluator's verdict is no estimate of the odds but only a
+// measure of who leads in the race, so the Outcomes are crude: they know
+// nothing of primes, anchors, or timing, and they are too sure of who wins.
+// They serve to rank moves and to judge the leaves of a search, not to decide
+// whether to double. In a race, there are no shots, and this is
+// RaceEvaluator.
+var ConservativeEvaluator Evaluator = EvaluatorFunc(func(b *brd.Board) analysis.Outcomes {
+	o := RaceEvaluator.Evaluate(b)
+	shots, total := b.Shots(b.Roller)
+	if total == 0 {
+		return o
+	}
+	target := shots[0]
+	for _, s := range shots[1:] {
+		if s.Total() > target.Total() {
+			target = s
+		}
+	}
+	hit := *b
+	hit.Pips[target.Point].Subtract()
+	bar := brd.BarRedPip
+	if b.Roller == brd.White {
+		bar = brd.BarWhitePip
+	}
+	hit.Pips[bar].Add(b.Roller)
+	p := float64(total) / 36
+	var result analysis.Outcomes
+	addWeighted(&result, 1-p, o)
+	addWeighted(&result, p, RaceEvaluator.Evaluate(&hit))
+	return result
+})
+
+// Adds w times x to sum.
+func addWeighted(sum *analysis.Outcomes, w float64, x analysis.Outcomes) {
+	sum.Win += w * x.Win
+	sum.WinGammon += w * x.WinGammon
+	sum.WinBackgammon += w * x.WinBackgammon
+	sum.LoseGammon += w * x.LoseGammon
+	sum.LoseBackgammon += w * x.LoseBackgammon
+}
+
+// The brd.Analysis of a ChooserFromEvaluator() or of a MakePlayerConservative()
+// with foresight.
+type Evaluation struct {
+	Outcomes analysis.Outcomes // for the Roller
+	Equity   float64           // per analysis.Outcomes.Equity()
+	Plies    int               // how many rolls ahead we looked
+}
+
+func (e Evaluation) Summary() string {
+	if e.Plies > 0 {
+		return fmt.Sprintf("%d-ply equity %+.3f: %v", e.Plies, e.Equity, e.Outcomes.Summary())
+	}
+	return fmt.Sprintf("equity %+.3f: %v", e.Equity, e.Outcomes.Summary())
+}
+
+// Returns a brd.Chooser that ranks the choices by the equity, aware of the
+// MatchScore, that e gives them, all at once if e is a BatchEvaluator. Ties go
+// to the earlier choice.
+func ChooserFromEvaluator(e Evaluator) brd.Chooser {
+	var chooser brd.Chooser
+	chooser = func(choices []*brd.Board) []brd.AnalyzedBoard {
+		if choices[0].Bonus != brd.ZeroDie && len(choices) > 1 {
+			return chooseBonus(chooser, choices)
+		}
+		var batch []analysis.Outcomes
+		if be, ok := e.(BatchEvaluator); ok {
+			batch = be.EvaluateBatch(choices)
+		}
+		result := make([]brd.AnalyzedBoard, len(choices))
+		for i, b := range choices {
+			var outcomes analysis.Outcomes
+			if batch != nil {
+				outcomes = batch[i]
+			} else {
+				outcomes = e.Evaluate(b)
+			}
+			result[i] = brd.AnalyzedBoard{
+				Board:    b,
+				Analysis: Evaluation{Outcomes: outcomes, Equity: outcomes.Equity(b, b.Roller)},
+			}
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Analysis.(Evaluation).Equity > result[j].Analysis.(Evaluation).Equity
+		})
+		return result
+	}
+	return chooser
+}
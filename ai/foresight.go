@@ -0,0 +1,116 @@
+package ai
+
+import (
+	"sort"
+
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// A move filter like gnubg's: having evaluated the candidates at some number
+// of plies, we look more deeply at no more than keep of them, and only at
+// those within threshold of the best equity.
+type moveFilter struct {
+	keep      int
+	threshold float64
+}
+
+// moveFilters[k] prunes the candidates after k plies. The last one applies to
+// any deeper search.
+var moveFilters = [...]moveFilter{{8, 0.16}, {3, 0.08}, {2, 0.04}}
+
+// The number of candidates, best first, that survive f.
+func (f moveFilter) numKept(ranked []brd.AnalyzedBoard) int {
+	best := ranked[0].Analysis.(Evaluation).Equity
+	n := 1
+	for n < len(ranked) && n < f.keep && ranked[n].Analysis.(Evaluation).Equity >= best-f.threshold {
+		n++
+	}
+	return n
+}
+
+// An expectiminimax search on behalf of me. The opponent replies as other
+// would, and ConservativeEvaluator judges the leaves, contact or not.
+type foresight struct {
+	me    brd.Checker
+	other brd.Chooser
+}
+
+func makeForesightful(plies int, other brd.Chooser) brd.Chooser {
+	var chooser brd.Chooser
+	chooser = func(choices []*brd.Board) []brd.AnalyzedBoard {
+		if choices[0].Bonus != brd.ZeroDie && len(choices) > 1 {
+			return chooseBonus(chooser, choices)
+		}
+		f := &foresight{me: choices[0].Roller, other: other}
+		return f.rank(choices, plies)
+	}
+	return chooser
+}
+
+// Ranks choices, all of them mine, by equity. The best few are evaluated at
+// the given number of plies and the rest, per moveFilters, at fewer.
+func (f *foresight) rank(choices []*brd.Board, plies int) []brd.AnalyzedBoard {
+	result := make([]brd.AnalyzedBoard, len(choices))
+	for i, c := range choices {
+		result[i] = brd.AnalyzedBoard{Board: c, Analysis: f.evaluate(c, 0)}
+	}
+	sortByEquity(result)
+	for k := 1; k <= plies; k++ {
+		filter := moveFilters[len(moveFilters)-1]
+		if k-1 < len(moveFilters) {
+			filter = moveFilters[k-1]
+		}
+		n := filter.numKept(result)
+		for i := 0; i < n; i++ {
+			result[i].Analysis = f.evaluate(result[i].Board, k)
+		}
+		sortByEquity(result[:n])
+	}
+	return result
+}
+
+func sortByEquity(a []brd.AnalyzedBoard) {
+	sort.SliceStable(a, func(i, j int) bool {
+		return a[i].Analysis.(Evaluation).Equity > a[j].Analysis.(Evaluation).Equity
+	})
+}
+
+func (f *foresight) evaluate(b *brd.Board, plies int) Evaluation {
+	o := f.outcomes(b, plies)
+	return Evaluation{Outcomes: o, Equity: o.Equity(b, b.Roller), Plies: plies}
+}
+
+// b.Roller's Outcomes, averaged over the next plies rolls.
+func (f *foresight) outcomes(b *brd.Board, plies int) analysis.Outcomes {
+	if o, over := analysis.FinalOutcomes(b); over {
+		return o
+	}
+	if plies == 0 || b.Bonus != brd.ZeroDie {
+		// TODO(chandler37): Look past an AceyDeucey Bonus.
+		return ConservativeEvaluator.Evaluate(b)
+	}
+	var result analysis.Outcomes
+	for _, wr := range brd.AllRolls() {
+		candidates := b.ContinuationsForRoll(wr.Roll)
+		var o analysis.Outcomes
+		reply := candidates[0]
+		if reply.Roller == f.me {
+			best := f.rank(candidates, plies-1)[0]
+			reply, o = best.Board, best.Analysis.(Evaluation).Outcomes
+		} else {
+			if len(candidates) > 1 {
+				if analyzed := f.other(candidates); len(analyzed) > 0 {
+					reply = analyzed[0].Board
+				}
+			}
+			o = f.outcomes(reply, plies-1)
+		}
+		if reply.Roller != b.Roller {
+			o = o.Flip()
+		}
+		addWeighted(&result, wr.Probability(), o)
+		brd.OptionallyReturnBoardsToPool(candidates, nil)
+	}
+	return result
+}
@@ -1,39 +1,52 @@
 package ai
 
 import (
+	"math"
+	"sort"
+
+	"github.com/chandler37/gobackgammon/bearoff"
 	"github.com/chandler37/gobackgammon/brd"
 )
 
-// A brd.Chooser built for a race (a.k.a. a bear-off). It dislikes being
-// backgammoned or gammmoned and prefers to get as many checkers off as
-// possible. You would be silly to play this way if your opponent had a chance
-// to hit you.
-//
-// TODO(chandler37): To avoid being gammoned we try to maxMyCheckersAtHome, but
-// sometimes this might mean a move 7=>1 when that six would be better spent
-// moving the farthest checker, say on the 17, closer to home. ai_test.go has a
-// test case with a TODO demonstrating this.
+// A brd.Chooser built for a race (a.k.a. a bear-off). It maximizes its equity
+// per analysis.EstimateRace(), which weighs winning the race against winning
+// and saving gammons and, in a match, knows what they're worth at the
+// MatchScore. Among plays of exactly equal equity it brings its checkers home
+// without wasting pips inside its home board and bears off optimally per
+// bearoff.Default(). You would be silly to play this way if your opponent had
+// a chance to hit you.
 func PlayerRacer(choices []*brd.Board) []brd.AnalyzedBoard {
 	if len(choices) == 1 {
 		return nil
 	}
 	nextRound := converter(choices)
 	minimizer(
-		"minProbabilityOfGettingBackgammoned",
+		"minMyRaceEquityRank",
+		nextRound,
+		raceEquityRanker(choices))
+	// Moving 7=>1 brings a checker home but wastes five pips that could have
+	// moved another checker closer to home.
+	minimizer(
+		"minMyPipsOutsideHome",
+		nextRound,
+		func(b *brd.Board) int64 {
+			v := b.Perspective(b.Roller)
+			var result int64
+			for i := 7; i < 26; i++ {
+				result += int64((i - 6) * v.Mine[i])
+			}
+			return result
+		})
+	minimizer(
+		"minMyExpectedRollsToBearOff",
 		nextRound,
-		probabilityOfGettingBackgammoned)
+		myExpectedRollsToBearOff)
 	maximizer(
 		"maxMyCheckersBorneOff",
 		nextRound,
 		func(b *brd.Board) int64 {
-			pip := brd.BorneOffRedPip
-			if b.Roller == brd.White {
-				pip = brd.BorneOffWhitePip
-			}
-			return int64(b.Pips[pip].NumCheckers())
+			return int64(b.Perspective(b.Roller).Mine[0])
 		})
-	// TODO(chandler37): Care about the pip count of not-at-home checkers. See
-	// http://localhost:8000/game?s=qlYqKlWyUjIxJDKkioxgQQVhGcNZJnCWKZxlBmdBvGgM92O4CdyT4XBPQgXBIWSqVAsIAAD__w&t=
 	maximizer(
 		"maxMyCheckersAtHome",
 		nextRound,
@@ -50,6 +63,33 @@ func PlayerRacer(choices []*brd.Board) []brd.AnalyzedBoard {
 	return nextRound
 }
 
-// TODO(chandler37): Fix the following bug: it thought there were two choices
-// at the end:
-// http://localhost:8000/game?s=qlYqKlWyUjIxxBpSRfCQMjSGhVWRESyMimBhBBECWVpkAnddONx14QjXmSnVAgIAAP__&t=
+// Returns a function that gives, for each of choices, how many of the others
+// have a greater equity for the Roller per RaceEvaluator. Only plays of
+// exactly equal equity share a rank.
+func raceEquityRanker(choices []*brd.Board) func(*brd.Board) int64 {
+	equities := make(map[*brd.Board]float64, len(choices))
+	sorted := make([]float64, len(choices))
+	for i, c := range choices {
+		equities[c] = RaceEvaluator.Evaluate(c).Equity(c, c.Roller)
+		sorted[i] = equities[c]
+	}
+	sort.Float64s(sorted)
+	return func(b *brd.Board) int64 {
+		e := equities[b]
+		return int64(len(sorted) - sort.Search(len(sorted), func(i int) bool { return sorted[i] > e }))
+	}
+}
+
+// The Roller's bearoff.OneSided.ExpectedRolls() in millionths of a roll, or
+// zero if the Roller has checkers outside of its home board or the Variant is
+// LongNardy, where the opponent may occupy points in it.
+func myExpectedRollsToBearOff(b *brd.Board) int64 {
+	if b.Variant == brd.LongNardy {
+		return 0
+	}
+	h, ok := bearoff.HomeOf(b, b.Roller)
+	if !ok {
+		return 0
+	}
+	return int64(math.Round(1e6 * bearoff.Default().ExpectedRolls(h)))
+}
@@ -0,0 +1,441 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+const (
+	Red   = brd.Red
+	White = brd.White
+)
+
+// Red's checkers by distance from bearing off, i.e., by point. 25 is the
+// bar.
+func redAt(b *brd.Board, counts map[int]int) {
+	for i, n := range counts {
+		if i == 25 {
+			i = brd.BarRedPip
+		}
+		b.Pips[i].Reset(n, Red)
+	}
+}
+
+// White's checkers by distance from bearing off. 25 is the bar.
+func whiteAt(b *brd.Board, counts map[int]int) {
+	for i, n := range counts {
+		if i == 25 {
+			b.Pips[brd.BarWhitePip].Reset(n, White)
+			continue
+		}
+		b.Pips[25-i].Reset(n, White)
+	}
+}
+
+func newPosition(roller brd.Checker, red, white map[int]int) *brd.Board {
+	b := brd.New(true)
+	b.Pips = brd.Points28{}
+	redAt(b, red)
+	whiteAt(b, white)
+	numRed, numWhite := 0, 0
+	for _, n := range red {
+		numRed += n
+	}
+	for _, n := range white {
+		numWhite += n
+	}
+	b.Pips[brd.BorneOffRedPip].Reset(15-numRed, Red)
+	b.Pips[brd.BorneOffWhitePip].Reset(15-numWhite, White)
+	b.Roller = roller
+	b.Roll = brd.Roll{}
+	if iv := b.Invalidity(brd.IgnoreRollValidity); iv != "" {
+		panic(iv)
+	}
+	return b
+}
+
+func TestKeithAndThorpCounts(t *testing.T) {
+	type example struct {
+		Red, White  map[int]int
+		RedKeith    int
+		WhiteKeith  int
+		RedThorp    int
+		WhiteThorp  int
+		RedPips     int
+		WhitePips   int
+		Explanation string
+	}
+	examples := [...]example{
+		example{
+			map[int]int{1: 3, 2: 2, 3: 5, 5: 2, 6: 3},
+			map[int]int{6: 15},
+			58, 92, 78, 119, 50, 90,
+			"Red: 50 pips, +4 for the one point, +1 for the two, +2 for the three, +1 for the empty four; White: 90, +2 for the empty four and five"},
+		example{
+			map[int]int{8: 1},
+			map[int]int{1: 1},
+			11, 4, 10, 3, 8, 1,
+			"Red: all three of the four, five and six points are empty; White: Thorp is 1+2+1-1"},
+		example{
+			map[int]int{},
+			map[int]int{24: 2, 13: 5, 8: 3, 6: 5},
+			3, 169, 0, 196, 0, 167,
+			"Red has borne off; White has the starting position"},
+	}
+	for i, ex := range examples {
+		b := newPosition(Red, ex.Red, ex.White)
+		if x := b.PipCount(Red); x != ex.RedPips {
+			t.Errorf("i=%d red pips=%d", i, x)
+		}
+		if x := b.PipCount(White); x != ex.WhitePips {
+			t.Errorf("i=%d white pips=%d", i, x)
+		}
+		if x := KeithCount(b, Red); x != ex.RedKeith {
+			t.Errorf("i=%d red Keith=%d (%s)", i, x, ex.Explanation)
+		}
+		if x := KeithCount(b, White); x != ex.WhiteKeith {
+			t.Errorf("i=%d white Keith=%d (%s)", i, x, ex.Explanation)
+		}
+		if x := ThorpCount(b, Red); x != ex.RedThorp {
+			t.Errorf("i=%d red Thorp=%d (%s)", i, x, ex.Explanation)
+		}
+		if x := ThorpCount(b, White); x != ex.WhiteThorp {
+			t.Errorf("i=%d white Thorp=%d (%s)", i, x, ex.Explanation)
+		}
+	}
+}
+
+func TestEffectivePipCount(t *testing.T) {
+	type example struct {
+		Red           map[int]int
+		ExpectedRolls float64
+	}
+	examples := [...]example{
+		example{map[int]int{}, 0},
+		example{map[int]int{1: 1}, 1},
+		example{map[int]int{1: 2}, 1},
+		// Any roll with a 1 but <1 1> leaves a checker behind.
+		example{map[int]int{2: 2}, 1 + 10.0/36},
+		// <1 2>, <1 3>, <1 4>, <2 3>, and <1 1> fail to bear off a checker
+		// on the six point, and any roll finishes the job.
+		example{map[int]int{6: 1}, 1.25},
+		example{map[int]int{1: 4}, 1 + 30.0/36},
+	}
+	for i, ex := range examples {
+		b := newPosition(Red, ex.Red, map[int]int{6: 15})
+		// The bearoff database stores float32s.
+		if x := EffectivePipCount(b, Red); math.Abs(x-ex.ExpectedRolls*PipsPerRoll) > 1e-5 {
+			t.Errorf("i=%d EPC=%v but expected %v rolls", i, x, ex.ExpectedRolls)
+		}
+	}
+	b := newPosition(Red, map[int]int{6: 3, 5: 3, 4: 3, 3: 2, 2: 2, 1: 2}, map[int]int{6: 15})
+	home := EffectivePipCount(b, Red)
+	if x := fmt.Sprintf("%.3f", home); x != "66.468" {
+		t.Errorf("EPC=%v", x)
+	}
+	// Outside of home, the estimate uses that very position's wastage.
+	b = newPosition(Red, map[int]int{8: 1, 5: 3, 4: 3, 3: 2, 2: 2, 1: 2, 6: 2}, map[int]int{6: 15})
+	if x, y := EffectivePipCount(b, Red), home+2; math.Abs(x-y) > 1e-9 {
+		t.Errorf("EPC=%v but expected %v", x, y)
+	}
+	if x := EffectivePipCount(b, White); x <= 90 {
+		t.Errorf("wastage is never negative: %v", x)
+	}
+}
+
+func TestRaceWinProbability(t *testing.T) {
+	type example struct {
+		Roller      brd.Checker
+		Red, White  map[int]int
+		Probability string
+	}
+	examples := [...]example{
+		example{Red, map[int]int{}, map[int]int{1: 1}, "1.000"},
+		example{White, map[int]int{}, map[int]int{1: 1}, "0.000"},
+		example{Red, map[int]int{24: 2, 13: 5, 8: 3, 6: 5}, map[int]int{24: 2, 13: 5, 8: 3, 6: 5}, "0.558"},
+		// Exact, because both sides are home:
+		example{Red, map[int]int{6: 5, 5: 5, 4: 5}, map[int]int{6: 5, 5: 5, 4: 5}, "0.596"},
+		example{Red, map[int]int{6: 1}, map[int]int{1: 1}, "0.750"},
+		example{Red, map[int]int{6: 5, 5: 5, 4: 5}, map[int]int{6: 5, 5: 5, 4: 4, 10: 1}, "0.696"},
+	}
+	for i, ex := range examples {
+		b := newPosition(ex.Roller, ex.Red, ex.White)
+		if x := fmt.Sprintf("%.3f", RaceWinProbability(b)); x != ex.Probability {
+			t.Errorf("i=%d probability=%v", i, x)
+		}
+		// Swapping colors changes nothing.
+		m := b.Mirror()
+		if x := fmt.Sprintf("%.3f", RaceWinProbability(m)); x != ex.Probability {
+			t.Errorf("i=%d mirrored probability=%v", i, x)
+		}
+	}
+}
+
+func TestCubeAdvice(t *testing.T) {
+	type example struct {
+		Red, White map[int]int
+		Owned      bool // Red owns the cube
+		Keith      string
+		Thorp      string
+		Estimate   string
+	}
+	// Red has 75 pips and White trails by four, six, eight, eight again and
+	// thirteen.
+	red := map[int]int{6: 5, 5: 5, 4: 5}
+	examples := [...]example{
+		example{
+			red,
+			map[int]int{6: 5, 5: 5, 4: 4, 8: 1},
+			false,
+			"no double/take",
+			"no double/take",
+			"no double/take"},
+		example{
+			red,
+			map[int]int{6: 5, 5: 5, 4: 4, 10: 1},
+			false,
+			"no double/take",
+			"double/take",
+			"double/take"},
+		example{
+			red,
+			map[int]int{6: 5, 5: 5, 4: 4, 12: 1},
+			false,
+			"double/take",
+			"double/take",
+			"double/take"},
+		example{
+			red,
+			map[int]int{6: 5, 5: 5, 4: 4, 12: 1},
+			true,
+			"double/take",
+			"double/take",
+			"double/take"},
+		example{
+			red,
+			map[int]int{6: 5, 5: 5, 4: 4, 17: 1},
+			false,
+			"double/pass",
+			"double/pass",
+			"double/pass"},
+		// Short races tell pips from percentages: Red's 33 pips become 37.7,
+		// which is 2.7 pips but 7.8% above White's 35.
+		example{
+			map[int]int{6: 2, 5: 2, 4: 2, 3: 1},
+			map[int]int{6: 2, 5: 2, 4: 2, 3: 1, 2: 1},
+			false,
+			"double/take",
+			"double/take",
+			"double/take"},
+	}
+	for i, ex := range examples {
+		b := newPosition(Red, ex.Red, ex.White)
+		if ex.Owned {
+			b.WhiteCanDouble = false
+			b.Stakes = 2
+		}
+		if x := KeithCubeAdvice(b).String(); x != ex.Keith {
+			t.Errorf("i=%d Keith: %v", i, x)
+		}
+		if x := ThorpCubeAdvice(b).String(); x != ex.Thorp {
+			t.Errorf("i=%d Thorp: %v", i, x)
+		}
+		if x := RaceCubeAdvice(b).String(); x != ex.Estimate {
+			t.Errorf("i=%d estimate: %v", i, x)
+		}
+	}
+}
+
+func TestAnalyzeRace(t *testing.T) {
+	b := newPosition(White, map[int]int{6: 5, 5: 5, 4: 4, 12: 1}, map[int]int{6: 5, 5: 5, 4: 5})
+	var a brd.Analysis = AnalyzeRace(b)
+	expected := "W on roll: pips 75-83 Keith 75-83 Thorp 102-110 EPC 82.3-90.3 win 73.0% Keith double/take Thorp double/take estimate double/take"
+	if x := a.Summary(); x != expected {
+		t.Errorf("summary=%v", x)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	type example struct {
+		Red, White map[int]int
+		Summary    string
+	}
+	start := map[int]int{24: 2, 13: 5, 8: 3, 6: 5}
+	examples := [...]example{
+		example{start, start, "contact 1.00"},
+		example{
+			map[int]int{6: 5, 5: 5, 4: 5},
+			map[int]int{12: 5, 10: 5, 6: 5},
+			"one-sided bearoff 1.00"},
+		example{
+			map[int]int{6: 5, 5: 5, 4: 5},
+			map[int]int{3: 5, 2: 5, 1: 5},
+			"two-sided bearoff 1.00"},
+		example{
+			map[int]int{11: 5, 5: 5, 4: 5},
+			map[int]int{13: 5, 10: 5, 6: 5},
+			"race 1.00"},
+		example{
+			// White's six-point board and Red on the bar
+			map[int]int{25: 1, 18: 1, 13: 5, 8: 3, 6: 5},
+			map[int]int{6: 3, 5: 2, 4: 2, 3: 2, 2: 2, 1: 2, 8: 2},
+			"closed out for r 1.00, blitz for W 1.00"},
+		example{
+			// White attacks two checkers on the bar with a three-point board.
+			map[int]int{25: 2, 13: 5, 8: 3, 6: 5},
+			map[int]int{13: 4, 8: 2, 6: 3, 5: 2, 4: 2, 2: 1, 24: 1},
+			"blitz for W 0.75, contact 0.25"},
+		example{
+			// Red holds White's one and three points.
+			map[int]int{24: 2, 22: 3, 18: 1, 13: 2, 8: 2, 6: 3, 5: 2},
+			map[int]int{8: 3, 6: 4, 5: 4, 4: 2, 2: 2},
+			"backgame for r 1.00"},
+		example{
+			// Red's anchor on White's five point is in the way of White's
+			// midpoint.
+			map[int]int{20: 2, 13: 2, 8: 3, 6: 4, 5: 2, 4: 2},
+			map[int]int{13: 4, 9: 2, 6: 4, 4: 3, 3: 2},
+			"holding game for r 1.00"},
+		example{
+			// Each has a five-point prime and two checkers behind the other's.
+			map[int]int{24: 1, 23: 1, 8: 2, 7: 3, 6: 3, 5: 3, 4: 2},
+			map[int]int{22: 2, 9: 2, 8: 3, 7: 3, 6: 2, 5: 3},
+			"prime-vs-prime 0.75, contact 0.25"},
+		example{
+			// White's home board has crashed while Red holds an anchor.
+			map[int]int{21: 2, 13: 4, 8: 4, 6: 5},
+			map[int]int{11: 2, 6: 2, 3: 2, 2: 4, 1: 5},
+			"crashed for W 1.00, holding game for r 0.33"},
+		example{
+			// White bears off against Red's anchor. Borne-off checkers
+			// aren't dead.
+			map[int]int{24: 2, 13: 5, 8: 3, 6: 5},
+			map[int]int{6: 2, 5: 2, 4: 1},
+			"contact 1.00"},
+	}
+	for i, ex := range examples {
+		b := newPosition(Red, ex.Red, ex.White)
+		c := Classify(b)
+		if x := c.Summary(); x != ex.Summary {
+			t.Errorf("i=%d summary=%v board=%v features=%+v", i, x, b, c.Features)
+		}
+		if c.Best() != c.Candidates[0] || c.Best().Class.IsRace() != b.Racing() {
+			t.Errorf("i=%d", i)
+		}
+		for _, p := range [2]brd.Checker{White, Red} {
+			if b.Racing() {
+				continue
+			}
+			crashed := false
+			for _, candidate := range c.Candidates {
+				crashed = crashed || (candidate.Class == Crashed && candidate.Player == p && candidate.Score >= 0.5)
+			}
+			if Dead(b, p) != c.Features[p].Dead || IsCrashed(b, p) != crashed {
+				t.Errorf("i=%d p=%v Dead=%d IsCrashed=%v", i, p, Dead(b, p), IsCrashed(b, p))
+			}
+		}
+		if x, y := Classify(b.Mirror()).Summary(), strings.NewReplacer("for W", "for r", "for r", "for W").Replace(ex.Summary); x != y {
+			t.Errorf("i=%d mirrored summary=%v", i, x)
+		}
+	}
+	c := Classify(newPosition(Red, start, start))
+	expected := SideFeatures{
+		PipCount:     167,
+		OnBar:        0,
+		CheckersBack: 2,
+		Anchors:      1,
+		HomePoints:   1,
+		MaxPrime:     0,
+		Trapped:      0,
+		Dead:         0,
+		Timing:       77,
+	}
+	if c.Features[White] != expected || c.Features[Red] != expected {
+		t.Errorf("features=%+v", c.Features)
+	}
+}
+
+func TestEstimateRace(t *testing.T) {
+	type example struct {
+		Red, White map[int]int
+		Summary    string
+		Equity     string
+	}
+	examples := [...]example{
+		// Nobody can be gammoned, and the bearoff database is exact.
+		example{
+			map[int]int{6: 5, 5: 5, 4: 4},
+			map[int]int{6: 5, 5: 5, 4: 4},
+			"win 59.8% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)",
+			"+0.197"},
+		// White hasn't borne off and has a straggler in Red's home board.
+		example{
+			map[int]int{3: 4, 2: 4, 1: 4},
+			map[int]int{6: 5, 5: 5, 4: 4, 20: 1},
+			"win 100.0% (gammon 2.8% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)",
+			"+1.028"},
+		// Red is way behind, with five checkers in White's home board.
+		example{
+			map[int]int{6: 5, 13: 5, 21: 5},
+			map[int]int{2: 5, 1: 4},
+			"win 0.0% (gammon 0.0% backgammon 0.0%) lose gammon 100.0% (backgammon 0.7%)",
+			"-2.007"},
+	}
+	for i, ex := range examples {
+		b := newPosition(Red, ex.Red, ex.White)
+		c := EstimateRace(b, Red)
+		if x := c.Summary(); x != ex.Summary {
+			t.Errorf("i=%d summary=%v", i, x)
+		}
+		if x := fmt.Sprintf("%+.3f", c.Equity(b, Red)); x != ex.Equity {
+			t.Errorf("i=%d equity=%v", i, x)
+		}
+		// Swapping colors changes nothing.
+		if x := EstimateRace(b.Mirror(), White).Summary(); x != ex.Summary {
+			t.Errorf("i=%d mirrored summary=%v", i, x)
+		}
+		if x, y := c.Flip().Equity(b, White), -c.Equity(b, Red); math.Abs(x-y) > 1e-9 {
+			t.Errorf("i=%d flipped equity=%v", i, x)
+		}
+		b.Rules.NoGammons = true
+		if x := EstimateRace(b, Red); x.WinGammon != 0 || x.LoseGammon != 0 || x.Win != c.Win {
+			t.Errorf("i=%d no gammons: %v", i, x.Summary())
+		}
+	}
+	// Both sides are home, so the chance of winning is exact.
+	b := newPosition(Red, map[int]int{6: 1}, map[int]int{1: 1})
+	if x := fmt.Sprintf("%.3f", EstimateRace(b, Red).Win); x != "0.750" {
+		t.Errorf("win=%v", x)
+	}
+	// Red is 2-away and White 1-away. A single win leaves the match even, but
+	// a gammon wins it.
+	b = newPosition(Red, map[int]int{3: 4, 2: 4, 1: 4}, map[int]int{6: 5, 5: 5, 4: 4, 20: 1})
+	b.SetScore(brd.Score{Goal: 5, WhiteScore: 4, RedScore: 3})
+	if x := fmt.Sprintf("%+.3f", EstimateRace(b, Red).Equity(b, Red)); x != "+0.028" {
+		t.Errorf("match equity=%v", x)
+	}
+}
+
+func TestMatchWinningChances(t *testing.T) {
+	for a := 1; a < 30; a++ {
+		if x := MatchWinningChances(a, a); math.Abs(x-0.5) > 1e-9 {
+			t.Errorf("MWC(%d, %d)=%v", a, a, x)
+		}
+		for b := 1; b < 30; b++ {
+			if x := MatchWinningChances(a, b) + MatchWinningChances(b, a); math.Abs(x-1) > 1e-9 {
+				t.Errorf("MWC(%d, %d)+MWC(%d, %d)=%v", a, b, b, a, x)
+			}
+			if a < b && MatchWinningChances(a, b) <= 0.5 {
+				t.Errorf("MWC(%d, %d)=%v", a, b, MatchWinningChances(a, b))
+			}
+		}
+	}
+	// Double match point.
+	if x := fmt.Sprintf("%.3f %.3f %.3f", MatchWinningChances(1, 1), MatchWinningChances(1, 2), MatchWinningChances(2, 1)); x != "0.500 0.700 0.300" {
+		t.Errorf("MWC=%v", x)
+	}
+}
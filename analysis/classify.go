@@ -0,0 +1,288 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// The kinds of game a position can be. Classify() explains whose game it is.
+type Class uint8
+
+const (
+	Contact         Class = iota // none of the below, or not clearly so
+	Race                         // nobody can hit anybody, and neither side is home
+	OneSidedBearoff              // a race in which one side is home
+	TwoSidedBearoff              // a race in which both sides are home
+	Crashed                      // the player's checkers are piling up deep in its home board
+	Backgame                     // the player holds two or more anchors and trails badly
+	HoldingGame                  // the player holds an advanced anchor and waits for a shot
+	PrimeVsPrime                 // each side has a prime with checkers trapped behind it
+	Blitz                        // the player attacks checkers on the bar with its home board
+	ClosedOut                    // the player is on the bar against a six-point board
+)
+
+func (c Class) String() string {
+	switch c {
+	case Contact:
+		return "contact"
+	case Race:
+		return "race"
+	case OneSidedBearoff:
+		return "one-sided bearoff"
+	case TwoSidedBearoff:
+		return "two-sided bearoff"
+	case Crashed:
+		return "crashed"
+	case Backgame:
+		return "backgame"
+	case HoldingGame:
+		return "holding game"
+	case PrimeVsPrime:
+		return "prime-vs-prime"
+	case Blitz:
+		return "blitz"
+	case ClosedOut:
+		return "closed out"
+	default:
+		return fmt.Sprintf("Class(%d)", int(c))
+	}
+}
+
+// Whether c is Race or a bear-off, where nobody can hit anybody.
+func (c Class) IsRace() bool {
+	return c == Race || c == OneSidedBearoff || c == TwoSidedBearoff
+}
+
+// One way of seeing a position. Player is whose game it is (the one who is
+// crashed, plays the backgame or holding game, blitzes, or is closed out), or
+// NoChecker for Contact, PrimeVsPrime, and the races.
+type Candidate struct {
+	Class  Class
+	Player brd.Checker
+	Score  float64 // in [0, 1]; 1 means unmistakably so
+}
+
+func (c Candidate) String() string {
+	if c.Player == brd.NoChecker {
+		return fmt.Sprintf("%v %.2f", c.Class, c.Score)
+	}
+	return fmt.Sprintf("%v for %v %.2f", c.Class, c.Player, c.Score)
+}
+
+// What Classify() based its decision on, for one player. Distances are in pips
+// from bearing off.
+type SideFeatures struct {
+	PipCount     int
+	OnBar        int // including AceyDeucey's checkers yet to enter
+	CheckersBack int // on the bar or in the opponent's home board
+	Anchors      int // points made in the opponent's home board
+	HomePoints   int // points made in the player's own home board
+	MaxPrime     int // the longest run of four or more made points, or zero
+	Trapped      int // the opponent's checkers behind the player's longest prime
+	Dead         int // checkers on the one and two points
+	Timing       int // pips the player can play without moving checkers already home
+}
+
+// The result of Classify(). The best Candidate comes first, and its Score is
+// our confidence in it. Features is indexed by brd.Checker.
+//
+// It is a brd.Analysis.
+type Classification struct {
+	Candidates []Candidate
+	Features   [3]SideFeatures
+}
+
+func (c Classification) Best() Candidate {
+	return c.Candidates[0]
+}
+
+func (c Classification) Summary() string {
+	parts := make([]string, len(c.Candidates))
+	for i, candidate := range c.Candidates {
+		parts[i] = candidate.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Labels b with the kind of game it is, considering checkers, anchors and
+// timing. A race is unmistakable. Any other Class competes with Contact, whose
+// Score is what the others leave over, and the Candidates with nonzero Scores
+// come out in decreasing order of Score.
+//
+// Classification doesn't depend on who is on roll. LongNardy is always a Race.
+func Classify(b *brd.Board) Classification {
+	if b.Variant == brd.LongNardy {
+		return Classification{Candidates: []Candidate{Candidate{Race, brd.NoChecker, 1}}}
+	}
+	var d [3]distanceCounts
+	result := Classification{}
+	for _, p := range [2]brd.Checker{brd.White, brd.Red} {
+		d[p] = distances(b, p)
+		result.Features[p].PipCount = b.PipCount(p)
+	}
+	if b.Racing() {
+		class := Race
+		_, whiteHome := d[brd.White].home()
+		_, redHome := d[brd.Red].home()
+		if whiteHome && redHome {
+			class = TwoSidedBearoff
+		} else if whiteHome || redHome {
+			class = OneSidedBearoff
+		}
+		result.Candidates = []Candidate{Candidate{class, brd.NoChecker, 1}}
+		return result
+	}
+	for _, p := range [2]brd.Checker{brd.White, brd.Red} {
+		me, them := &d[p], &d[p.OtherColor()]
+		f := &result.Features[p]
+		f.OnBar = me[25]
+		f.Dead = me[1] + me[2]
+		for i := 1; i < 26; i++ {
+			made := me[i] > 1 && i < 25
+			switch {
+			case i < 7 && made:
+				f.HomePoints++
+			case i > 18:
+				f.CheckersBack += me[i]
+				if made {
+					f.Anchors++
+				}
+			}
+			if i > 6 {
+				f.Timing += (i - 6) * me[i]
+			}
+		}
+		length, lo := longestPrime(me)
+		f.MaxPrime = length
+		if length > 0 {
+			// The opponent's checker k pips from bearing off is 25-k pips
+			// from bearing off in our terms, and it is trapped if it has yet
+			// to pass lo.
+			for k := 26 - lo; k < 26; k++ {
+				f.Trapped += them[k]
+			}
+		}
+	}
+	var others float64
+	add := func(class Class, player brd.Checker, score float64) {
+		if score > 0 {
+			result.Candidates = append(result.Candidates, Candidate{class, player, clamp(score)})
+			if score > others {
+				others = clamp(score)
+			}
+		}
+	}
+	white, red := &result.Features[brd.White], &result.Features[brd.Red]
+	for _, p := range [2]brd.Checker{brd.White, brd.Red} {
+		o := p.OtherColor()
+		me, them := &result.Features[p], &result.Features[o]
+		if me.OnBar > 0 && them.HomePoints == 6 {
+			add(ClosedOut, p, 1)
+		}
+		if me.OnBar > 0 && me.Anchors == 0 {
+			add(Blitz, o, float64(them.HomePoints+me.OnBar-2)/4)
+		}
+		if deficit := me.PipCount - them.PipCount; me.Anchors > 1 && deficit > 0 {
+			add(Backgame, p, float64(deficit)/90)
+		}
+		if anchor := holdingAnchor(&d[p]); anchor > 0 && me.Anchors < 2 {
+			// The opponent's checkers outside its home board that have yet to
+			// get past our anchor:
+			mustPass := 0
+			for k := max(26-anchor, 7); k < 26; k++ {
+				mustPass += d[o][k]
+			}
+			score := float64(mustPass) / 6
+			if me.PipCount < them.PipCount {
+				score /= 2
+			}
+			add(HoldingGame, p, score)
+		}
+		add(Crashed, p, crashedScore(me.Dead))
+	}
+	if white.Trapped > 0 && red.Trapped > 0 {
+		add(PrimeVsPrime, brd.NoChecker, float64(minInt(white.MaxPrime, red.MaxPrime)-2)/4)
+	}
+	result.Candidates = append(result.Candidates, Candidate{Contact, brd.NoChecker, 1 - others})
+	sort.SliceStable(result.Candidates, func(i, j int) bool {
+		return result.Candidates[i].Score > result.Candidates[j].Score
+	})
+	for len(result.Candidates) > 1 && result.Candidates[len(result.Candidates)-1].Score == 0 {
+		result.Candidates = result.Candidates[:len(result.Candidates)-1]
+	}
+	return result
+}
+
+// The Score of the Crashed Candidate for a player with the given number of
+// dead checkers, before clamping.
+func crashedScore(dead int) float64 {
+	return float64(dead-4) / 4
+}
+
+// The checkers player has on its one and two points, i.e.,
+// SideFeatures.Dead, without the rest of Classify().
+func Dead(b *brd.Board, player brd.Checker) int {
+	if player == brd.White {
+		return b.Pips[24].Num(player) + b.Pips[23].Num(player)
+	}
+	return b.Pips[1].Num(player) + b.Pips[2].Num(player)
+}
+
+// Whether, if there's contact, Classify() gives the Crashed Candidate for
+// player a Score of at least one half. Unlike Classify(), it doesn't
+// allocate.
+func IsCrashed(b *brd.Board, player brd.Checker) bool {
+	return crashedScore(Dead(b, player)) >= 0.5
+}
+
+// Returns the length of the longest run of points made by the player and the
+// distance of the run's nearest point, or zeroes. A prime needs at least four
+// points.
+func longestPrime(d *distanceCounts) (length, lo int) {
+	k := 0
+	for i := 1; i < 26; i++ {
+		if i < 25 && d[i] > 1 {
+			k++
+			continue
+		}
+		if k > length {
+			length, lo = k, i-k
+		}
+		k = 0
+	}
+	if length < 4 {
+		return 0, 0
+	}
+	return
+}
+
+// Returns the distance of the farthest-advanced anchor on the opponent's bar,
+// five or four point, or zero.
+func holdingAnchor(d *distanceCounts) int {
+	for _, i := range [3]int{18, 20, 21} {
+		if d[i] > 1 {
+			return i
+		}
+	}
+	return 0
+}
+
+func clamp(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
+
+func minInt(i, j int) int {
+	if i < j {
+		return i
+	}
+	return j
+}
@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"github.com/chandler37/gobackgammon/bearoff"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// The average number of pips a roll moves: 49/6.
+const PipsPerRoll = 49.0 / 6
+
+// The effective pip count (EPC): the expected number of rolls player needs to
+// bear off all its checkers, times PipsPerRoll. Unlike the raw pip count it
+// charges for wastage, the pips of big rolls spent bearing off checkers that
+// a smaller number would have borne off.
+//
+// The EPC is exact, up to the precision of bearoff.Default(), if all of
+// player's checkers are home. Otherwise we estimate
+// it as the pip count plus the wastage of the home board we'd have if each
+// checker outside came home to whichever of the four, five and six points
+// held the fewest checkers.
+//
+// The Variant must not be LongNardy.
+func EffectivePipCount(b *brd.Board, player brd.Checker) float64 {
+	d := distances(b, player)
+	return d.effectivePipCount()
+}
+
+func (d *distanceCounts) effectivePipCount() float64 {
+	db := bearoff.Default()
+	if h, ok := d.home(); ok {
+		return db.ExpectedRolls(h) * PipsPerRoll
+	}
+	var h bearoff.Home
+	for i := 1; i < 7; i++ {
+		h[i-1] = uint8(d[i])
+	}
+	for i := 7; i < 26; i++ {
+		for n := 0; n < d[i]; n++ {
+			arrival := 5
+			for j := 4; j > 2; j-- {
+				if h[j] < h[arrival] {
+					arrival = j
+				}
+			}
+			h[arrival]++
+		}
+	}
+	wastage := db.ExpectedRolls(h)*PipsPerRoll - float64(h.PipCount())
+	return float64(d.pipCount()) + wastage
+}
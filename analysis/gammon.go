@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/chandler37/gobackgammon/bearoff"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// Estimates the chances of onRoll, which is about to roll, in a race. The
+// Roller is irrelevant. Each side is assumed to race as fast as it can, so the
+// chances of saving gammons are, if anything, underestimated.
+//
+// For each player we estimate the distributions of the number of rolls it
+// needs to bear off all its checkers, to bear off its first checker, and to
+// get its last checker out of the opponent's home board, treating the players
+// independently. The first of these is exact if the player is home (see
+// bearoff.Default()); the rest are approximately normal per the reasoning of
+// RaceWinProbability(). The Rules' NoGammons and NoBackgammons are respected.
+func EstimateRace(b *brd.Board, onRoll brd.Checker) Outcomes {
+	// LongNardy's checkers never go through the opponent's home board in the
+	// race, and it doesn't count the backgammon.
+	backgammons := b.Variant != brd.LongNardy
+	me := newRollsToGo(b.Perspective(onRoll).Mine, backgammons)
+	them := newRollsToGo(b.Perspective(onRoll.OtherColor()).Mine, backgammons)
+	var c Outcomes
+	// I win on my nth roll if they need n or more, having rolled n-1 times. I
+	// lose on their nth roll if I need more than n.
+	for n, p := range me.allOff {
+		c.Win += p * them.allOff.atLeast(n)
+		c.WinGammon += p * them.firstOff.atLeast(n)
+		c.WinBackgammon += p * them.escape.atLeast(n)
+	}
+	for n, p := range them.allOff {
+		c.LoseGammon += p * me.firstOff.atLeast(n+1)
+		c.LoseBackgammon += p * me.escape.atLeast(n+1)
+	}
+	c.WinBackgammon = math.Min(c.WinBackgammon, c.WinGammon)
+	c.LoseBackgammon = math.Min(c.LoseBackgammon, c.LoseGammon)
+	if b.Rules.NoGammons {
+		c.WinGammon, c.WinBackgammon, c.LoseGammon, c.LoseBackgammon = 0, 0, 0, 0
+	} else if b.Rules.NoBackgammons {
+		c.WinBackgammon, c.LoseBackgammon = 0, 0
+	}
+	return c
+}
+
+// rolls[n] is the probability of needing exactly n rolls.
+type rollsDistribution []float64
+
+func (r rollsDistribution) atLeast(n int) (result float64) {
+	for i := n; i < len(r); i++ {
+		result += r[i]
+	}
+	return
+}
+
+// A number of rolls that is approximately normally distributed with the given
+// mean, but never less than min.
+func normalRolls(mean float64, min int) rollsDistribution {
+	if mean <= float64(min) {
+		mean = float64(min)
+	}
+	sigma := math.Sqrt(pipsPerRollVariance / (PipsPerRoll * PipsPerRoll) * mean)
+	if sigma == 0 {
+		r := make(rollsDistribution, min+1)
+		r[min] = 1
+		return r
+	}
+	phi := func(x float64) float64 { return 0.5 * math.Erfc(-(x-mean)/sigma/math.Sqrt2) }
+	r := make(rollsDistribution, int(math.Ceil(mean+6*sigma))+1)
+	for n := min; n < len(r); n++ {
+		r[n] = phi(float64(n)+0.5) - phi(float64(n)-0.5)
+	}
+	r[min] += phi(float64(min) - 0.5)
+	return r
+}
+
+type rollsToGo struct {
+	allOff, firstOff, escape rollsDistribution
+}
+
+func newRollsToGo(d distanceCounts, backgammons bool) (r rollsToGo) {
+	if h, ok := d.home(); ok {
+		r.allOff = bearoff.Default().Distribution(h)
+	} else {
+		// The effective pip count doesn't know that a checker outside may
+		// need a whole die just to come home, so we charge it a pip.
+		outside := 0
+		for i := 7; i < 26; i++ {
+			outside += d[i]
+		}
+		r.allOff = normalRolls((d.effectivePipCount()+float64(outside))/PipsPerRoll, 1)
+	}
+	// To save the gammon, bring every checker home and then bear off the
+	// nearest. We charge half a roll for wastage.
+	if d[0] > 0 {
+		r.firstOff = rollsDistribution{1}
+	} else {
+		pips, outside, nearest := 0, 0, 6
+		for i := 25; i > 0; i-- {
+			if i > 6 {
+				pips += (i - 6) * d[i]
+				outside += d[i]
+			} else if d[i] > 0 {
+				nearest = i
+			}
+		}
+		mean := math.Max(float64(pips+nearest)/PipsPerRoll, float64(outside+1)/2) + 0.5
+		r.firstOff = normalRolls(mean, 1)
+	}
+	// To escape a backgammon, get out of the opponent's home board, whose
+	// points are our 19 through 24.
+	pips := 0
+	for i := 19; i < 26 && backgammons; i++ {
+		pips += (i - 18) * d[i]
+	}
+	if pips == 0 {
+		r.escape = rollsDistribution{1}
+	} else {
+		r.escape = normalRolls(float64(pips)/PipsPerRoll+0.5, 1)
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// The chances of one player in a game. WinGammon includes WinBackgammon, and
+// LoseGammon includes LoseBackgammon; Win less WinGammon is the chance of a
+// single win. It is a brd.Analysis.
+type Outcomes struct {
+	Win            float64
+	WinGammon      float64
+	WinBackgammon  float64
+	LoseGammon     float64
+	LoseBackgammon float64
+}
+
+// The same chances from the opponent's point of view.
+func (c Outcomes) Flip() Outcomes {
+	return Outcomes{
+		Win:            1 - c.Win,
+		WinGammon:      c.LoseGammon,
+		WinBackgammon:  c.LoseBackgammon,
+		LoseGammon:     c.WinGammon,
+		LoseBackgammon: c.WinBackgammon,
+	}
+}
+
+// The probability of bearing off a checker before the opponent bears off all
+// of its checkers.
+func (c Outcomes) SaveGammon() float64 {
+	return 1 - c.LoseGammon
+}
+
+func (c Outcomes) Summary() string {
+	return fmt.Sprintf("win %.1f%% (gammon %.1f%% backgammon %.1f%%) lose gammon %.1f%% (backgammon %.1f%%)",
+		100*c.Win, 100*c.WinGammon, 100*c.WinBackgammon, 100*c.LoseGammon, 100*c.LoseBackgammon)
+}
+
+// If b.Roller has won, per b.Victor(), its Outcomes, which are certain.
+func FinalOutcomes(b *brd.Board) (c Outcomes, over bool) {
+	victor, stakes := b.Victor()
+	if victor == brd.NoChecker {
+		return
+	}
+	c.Win = 1
+	switch stakes / b.Stakes {
+	case 3:
+		c.WinBackgammon = 1
+		fallthrough
+	case 2:
+		c.WinGammon = 1
+	}
+	return c, true
+}
+
+// The equity of player if it has chances c, in units of b.Stakes for a money
+// game or, in a match, as twice player's match winning chances minus one. The
+// cube is ignored.
+func (c Outcomes) Equity(b *brd.Board, player brd.Checker) float64 {
+	singleWin := c.Win - c.WinGammon
+	gammonWin := c.WinGammon - c.WinBackgammon
+	singleLoss := 1 - c.Win - c.LoseGammon
+	gammonLoss := c.LoseGammon - c.LoseBackgammon
+	if b.MatchScore.Goal == 0 {
+		return singleWin + 2*gammonWin + 3*c.WinBackgammon - singleLoss - 2*gammonLoss - 3*c.LoseBackgammon
+	}
+	me, them := b.MatchScore.WhiteScore, b.MatchScore.RedScore
+	if player == brd.Red {
+		me, them = them, me
+	}
+	meAway, themAway := b.MatchScore.Goal-me, b.MatchScore.Goal-them
+	mwc := func(won, lost int) float64 {
+		return MatchWinningChances(meAway-won*b.Stakes, themAway-lost*b.Stakes)
+	}
+	result := singleWin*mwc(1, 0) + gammonWin*mwc(2, 0) + c.WinBackgammon*mwc(3, 0)
+	result += singleLoss*mwc(0, 1) + gammonLoss*mwc(0, 2) + c.LoseBackgammon*mwc(0, 3)
+	return 2*result - 1
+}
+
+// The fraction of games that end in a gammon (or backgammon) in
+// MatchWinningChances().
+const matchGammonRate = 0.2
+
+// The probability of winning a match needing meAway points while the opponent
+// needs themAway, if the players are equally strong and a fifth of the games
+// are gammons. The cube is ignored, as is the Crawford rule.
+//
+// TODO(chandler37): Use a real match equity table.
+func MatchWinningChances(meAway, themAway int) float64 {
+	if meAway <= 0 {
+		return 1
+	}
+	if themAway <= 0 {
+		return 0
+	}
+	if k := max(meAway, themAway) - (len(matchWinningChances) - 1); k > 0 {
+		// A long way to go. What matters is the difference.
+		meAway, themAway = meAway-k, themAway-k
+		if meAway <= 0 {
+			return 1
+		}
+		if themAway <= 0 {
+			return 0
+		}
+	}
+	return matchWinningChances[meAway][themAway]
+}
+
+var matchWinningChances = func() (t [26][26]float64) {
+	get := func(i, j int) float64 {
+		if i <= 0 {
+			return 1
+		}
+		if j <= 0 {
+			return 0
+		}
+		return t[i][j]
+	}
+	for i := 1; i < len(t); i++ {
+		for j := 1; j < len(t); j++ {
+			g := matchGammonRate
+			t[i][j] = 0.5*((1-g)*get(i-1, j)+g*get(i-2, j)) + 0.5*((1-g)*get(i, j-1)+g*get(i, j-2))
+		}
+	}
+	return
+}()
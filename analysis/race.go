@@ -0,0 +1,264 @@
+// Package analysis measures backgammon positions the way strong players do at
+// the table: race metrics, winning chances and cube advice.
+//
+// Unless documented otherwise, functions here assume that b.Roller is on roll
+// and deciding whether to double, which is the state of the brd.Board that
+// brd.Board.TakeTurn() hands to offerDouble.
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chandler37/gobackgammon/bearoff"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// A player's checkers indexed by the number of pips each needs to bear off,
+// i.e., brd.PerspectiveView.Mine.
+type distanceCounts [26]int
+
+func distances(b *brd.Board, player brd.Checker) distanceCounts {
+	if b.Variant == brd.LongNardy {
+		panic("race metrics do not apply to LongNardy")
+	}
+	return b.Perspective(player).Mine
+}
+
+func (d *distanceCounts) numInPlay() (result int) {
+	for _, n := range d[1:] {
+		result += n
+	}
+	return
+}
+
+func (d *distanceCounts) pipCount() (result int) {
+	for i, n := range d {
+		result += i * n
+	}
+	return
+}
+
+// Returns false if any checker in play is outside of the home board.
+func (d *distanceCounts) home() (h bearoff.Home, ok bool) {
+	for i := 7; i < 26; i++ {
+		if d[i] > 0 {
+			return
+		}
+	}
+	for i := 1; i < 7; i++ {
+		h[i-1] = uint8(d[i])
+	}
+	return h, true
+}
+
+// Tom Keith's count: the pip count plus
+//
+//	2 for each checker beyond one on the one point,
+//	1 for each checker beyond one on the two point,
+//	1 for each checker beyond three on the three point, and
+//	1 for each of the four, five and six points that is empty.
+//
+// See KeithCubeAdvice() for what to do with it.
+func KeithCount(b *brd.Board, player brd.Checker) int {
+	d := distances(b, player)
+	result := b.PipCount(player)
+	result += 2 * max(d[1]-1, 0)
+	result += max(d[2]-1, 0)
+	result += max(d[3]-3, 0)
+	for i := 4; i < 7; i++ {
+		if d[i] == 0 {
+			result++
+		}
+	}
+	return result
+}
+
+// Edward O. Thorp's count: the pip count plus two for each checker not yet
+// borne off, plus one for each checker on the one point, minus one for each
+// home board point occupied.
+//
+// See ThorpCubeAdvice() for what to do with it.
+func ThorpCount(b *brd.Board, player brd.Checker) int {
+	d := distances(b, player)
+	result := b.PipCount(player) + 2*d.numInPlay() + d[1]
+	for i := 1; i < 7; i++ {
+		if d[i] > 0 {
+			result--
+		}
+	}
+	return result
+}
+
+// What to do with the doubling cube: whether b.Roller should double and
+// whether its opponent should take if it does.
+type CubeAdvice struct {
+	Double bool
+	Take   bool
+}
+
+func (c CubeAdvice) String() string {
+	double := "no double"
+	if c.Double {
+		double = "double"
+	}
+	take := "pass"
+	if c.Take {
+		take = "take"
+	}
+	return double + "/" + take
+}
+
+// Whether b.Roller owns the cube rather than it being centered. Either way
+// b.Roller can double, or there'd be nothing to advise.
+func redoubling(b *brd.Board) bool {
+	return !b.WhiteCanDouble || !b.RedCanDouble
+}
+
+// Keith's rule: add one seventh to the Roller's KeithCount(). The Roller
+// should double if that is no more than four pips above its opponent's count
+// (three if redoubling), and the opponent should take if it is at least two
+// pips above the opponent's count.
+func KeithCubeAdvice(b *brd.Board) CubeAdvice {
+	roller := float64(KeithCount(b, b.Roller)) * 8 / 7
+	opponent := float64(KeithCount(b, b.Roller.OtherColor()))
+	margin := 4.0
+	if redoubling(b) {
+		margin = 3
+	}
+	return CubeAdvice{Double: roller <= opponent+margin, Take: roller >= opponent+2}
+}
+
+// Thorp's rule: if the Roller's ThorpCount() exceeds 30, add a tenth to
+// it. The Roller should double if that is no more than four pips above its
+// opponent's count (three if redoubling), and the opponent should take if it
+// is no more than two pips below the opponent's count.
+func ThorpCubeAdvice(b *brd.Board) CubeAdvice {
+	roller := ThorpCount(b, b.Roller)
+	if roller > 30 {
+		roller += roller / 10
+	}
+	opponent := ThorpCount(b, b.Roller.OtherColor())
+	margin := 4
+	if redoubling(b) {
+		margin = 3
+	}
+	return CubeAdvice{Double: roller <= opponent+margin, Take: roller >= opponent-2}
+}
+
+// The variance of the pips that one roll moves.
+const pipsPerRollVariance = 3066.0/36 - PipsPerRoll*PipsPerRoll
+
+// Estimates the probability that b.Roller, on roll, wins a race, i.e., bears
+// off all its checkers first.
+//
+// If both players are home, the answer is exact, up to the precision of
+// bearoff.Default(): the Roller wins in n rolls if its opponent needs more
+// than n-1.
+//
+// Otherwise each player's number of rolls to bear off is approximately normal
+// with mean EffectivePipCount()/PipsPerRoll and, like any sum of many rolls, a
+// variance of that mean times 0.277, the squared coefficient of variation of a
+// single roll. The Roller wins ties because it rolls first.
+//
+// If either player has borne off all its checkers, returns 1 or 0.
+func RaceWinProbability(b *brd.Board) float64 {
+	dMe, dThem := distances(b, b.Roller), distances(b, b.Roller.OtherColor())
+	if hMe, ok := dMe.home(); ok {
+		if hThem, ok := dThem.home(); ok {
+			return bearoffWinProbability(hMe, hThem)
+		}
+	}
+	me := EffectivePipCount(b, b.Roller) / PipsPerRoll
+	them := EffectivePipCount(b, b.Roller.OtherColor()) / PipsPerRoll
+	if me == 0 {
+		return 1
+	}
+	if them == 0 {
+		return 0
+	}
+	cv2 := pipsPerRollVariance / (PipsPerRoll * PipsPerRoll)
+	z := (them - me + 0.5) / math.Sqrt(cv2*(me+them))
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+func bearoffWinProbability(me, them bearoff.Home) float64 {
+	if me.NumCheckers() == 0 {
+		return 1
+	}
+	if them.NumCheckers() == 0 {
+		return 0
+	}
+	db := bearoff.Default()
+	var result float64
+	for n, p := range db.Distribution(me) {
+		if p > 0 {
+			result += p * (1 - db.OffIn(them, n-1))
+		}
+	}
+	return result
+}
+
+// Approximate money-game thresholds for pure races, where gammons are rare:
+// the Roller's winning chances at which doubling (or redoubling) begins and
+// beyond which the opponent should pass.
+const (
+	RaceDoublePoint   = 0.68
+	RaceRedoublePoint = 0.70
+	RaceTakePoint     = 0.785
+)
+
+// Advice from RaceWinProbability(). Ignores the MatchScore.
+func RaceCubeAdvice(b *brd.Board) CubeAdvice {
+	p := RaceWinProbability(b)
+	threshold := RaceDoublePoint
+	if redoubling(b) {
+		threshold = RaceRedoublePoint
+	}
+	return CubeAdvice{Double: p >= threshold, Take: p <= RaceTakePoint}
+}
+
+// Everything above in one place, indexed by brd.Checker where that makes
+// sense. It is a brd.Analysis.
+type RaceAnalysis struct {
+	Roller         brd.Checker
+	PipCount       [3]int
+	KeithCount     [3]int
+	ThorpCount     [3]int
+	EPC            [3]float64
+	WinProbability float64 // the Roller's
+	Keith          CubeAdvice
+	Thorp          CubeAdvice
+	Estimate       CubeAdvice // from WinProbability
+}
+
+func AnalyzeRace(b *brd.Board) RaceAnalysis {
+	r := RaceAnalysis{Roller: b.Roller}
+	for _, c := range [2]brd.Checker{brd.White, brd.Red} {
+		r.PipCount[c] = b.PipCount(c)
+		r.KeithCount[c] = KeithCount(b, c)
+		r.ThorpCount[c] = ThorpCount(b, c)
+		r.EPC[c] = EffectivePipCount(b, c)
+	}
+	r.WinProbability = RaceWinProbability(b)
+	r.Keith = KeithCubeAdvice(b)
+	r.Thorp = ThorpCubeAdvice(b)
+	r.Estimate = RaceCubeAdvice(b)
+	return r
+}
+
+func (r RaceAnalysis) Summary() string {
+	me, them := r.Roller, r.Roller.OtherColor()
+	return fmt.Sprintf(
+		"%v on roll: pips %d-%d Keith %d-%d Thorp %d-%d EPC %.1f-%.1f win %.1f%% Keith %v Thorp %v estimate %v",
+		me, r.PipCount[me], r.PipCount[them], r.KeithCount[me], r.KeithCount[them],
+		r.ThorpCount[me], r.ThorpCount[them], r.EPC[me], r.EPC[them], 100*r.WinProbability,
+		r.Keith, r.Thorp, r.Estimate)
+}
+
+func max(i, j int) int {
+	if i < j {
+		return j
+	}
+	return i
+}
@@ -0,0 +1,338 @@
+// Bearoff databases. A one-sided database gives, for every way of arranging a
+// player's last checkers on its home board, the distribution of the number of
+// rolls it needs to bear them all off. A two-sided database gives, for every
+// pair of such arrangements, exact winning chances and cubeful equities, and
+// with them perfect checker play and cube action.
+package bearoff
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// A player's checkers in its home board. Home[i] is the number on the point
+// i+1 pips from bearing off.
+type Home [6]uint8
+
+func (h Home) String() string {
+	return fmt.Sprintf("%v", [6]uint8(h))
+}
+
+func (h Home) NumCheckers() (result int) {
+	for _, n := range h {
+		result += int(n)
+	}
+	return
+}
+
+func (h Home) PipCount() (result int) {
+	for i, n := range h {
+		result += (i + 1) * int(n)
+	}
+	return
+}
+
+// Returns player's Home, or false if player has a checker in play outside of
+// its home board.
+func HomeOf(b *brd.Board, player brd.Checker) (h Home, ok bool) {
+	v := b.Perspective(player)
+	for i := 7; i < 26; i++ {
+		if v.Mine[i] > 0 {
+			return
+		}
+	}
+	for i := range h {
+		h[i] = uint8(v.Mine[i+1])
+	}
+	return h, true
+}
+
+// A one-sided bearoff database covering every Home with at most NumCheckers
+// checkers on the NumPoints points nearest to bearing off. Checkers are
+// played so as to minimize the expected number of rolls.
+//
+// Every die of every roll is playable in the home board, so the rule that you
+// must play as much of the roll as possible never constrains us.
+type OneSided struct {
+	NumPoints   int
+	NumCheckers int
+	entries     []entry // indexed by index()
+}
+
+// masses[k]/65535 is the probability of needing exactly first+k rolls.
+type entry struct {
+	expectedRolls float32
+	first         uint8
+	masses        []uint16
+}
+
+const maxNumPoints, maxNumCheckers = 6, 15
+
+// A two-sided database holds the square of the Homes of a one-sided one, so
+// this many checkers on six points come to 47 megabytes and fifteen would come
+// to tens of gigabytes.
+const maxTwoSidedCheckers = 7
+
+// Whether h is in the database.
+func (db *OneSided) Contains(h Home) bool {
+	for i := db.NumPoints; i < 6; i++ {
+		if h[i] > 0 {
+			return false
+		}
+	}
+	return h.NumCheckers() <= db.NumCheckers
+}
+
+func (db *OneSided) lookup(h Home) *entry {
+	if !db.Contains(h) {
+		panic(fmt.Sprintf("%v is not in a database of %d checkers on %d points", h, db.NumCheckers, db.NumPoints))
+	}
+	return &db.entries[db.index(h)]
+}
+
+// The expected number of rolls to bear off h. h must be in the database.
+func (db *OneSided) ExpectedRolls(h Home) float64 {
+	return float64(db.lookup(h).expectedRolls)
+}
+
+// The probability of bearing off h in exactly n rolls, for every n up to the
+// most h could need. h must be in the database.
+func (db *OneSided) Distribution(h Home) []float64 {
+	e := db.lookup(h)
+	result := make([]float64, int(e.first)+len(e.masses))
+	for k, m := range e.masses {
+		result[int(e.first)+k] = float64(m) / 65535
+	}
+	return result
+}
+
+// The probability of bearing off h in n or fewer rolls. h must be in the
+// database.
+func (db *OneSided) OffIn(h Home, n int) (result float64) {
+	e := db.lookup(h)
+	for k, m := range e.masses {
+		if int(e.first)+k > n {
+			break
+		}
+		result += float64(m) / 65535
+	}
+	if result > 1 {
+		result = 1
+	}
+	return
+}
+
+// The number of positions in a database of numCheckers checkers on numPoints
+// points: (numPoints+numCheckers) choose numPoints.
+func numPositions(numPoints, numCheckers int) int {
+	return binomial(numPoints+numCheckers, numPoints)
+}
+
+func binomial(n, k int) int {
+	if k < 0 || k > n {
+		return 0
+	}
+	result := 1
+	for i := 1; i <= k; i++ {
+		result = result * (n - k + i) / i
+	}
+	return result
+}
+
+func (db *OneSided) index(h Home) int {
+	return rank(db.NumPoints, h)
+}
+
+// Ranks h among the positions of a database of numPoints points. Write h out
+// as, for each point, a star per checker followed by a bar, and then a star
+// per missing checker. That's numPoints bars among numPoints+numCheckers
+// symbols, and the combinatorial number system ranks the set of the bars'
+// positions. The empty Home ranks zero.
+func rank(numPoints int, h Home) (result int) {
+	position := -1
+	for i := 0; i < numPoints; i++ {
+		position += int(h[i]) + 1
+		result += binomial(position, i+1)
+	}
+	return
+}
+
+// Every Home with at most numCheckers checkers on the numPoints points nearest
+// to bearing off, in increasing order of pip count. Every play lowers the pip
+// count, so each Home comes after those it can lead to.
+func allHomes(numPoints, numCheckers int) []Home {
+	var homes []Home
+	var enumerate func(h Home, point, remaining int)
+	enumerate = func(h Home, point, remaining int) {
+		if point == numPoints {
+			homes = append(homes, h)
+			return
+		}
+		for k := 0; k <= remaining; k++ {
+			h[point] = uint8(k)
+			enumerate(h, point+1, remaining-k)
+		}
+	}
+	enumerate(Home{}, 0, numCheckers)
+	sort.SliceStable(homes, func(i, j int) bool { return homes[i].PipCount() < homes[j].PipCount() })
+	return homes
+}
+
+// Builds the database. It takes about a second for the full fifteen checkers
+// on six points.
+func Generate(numPoints, numCheckers int) *OneSided {
+	if numPoints < 1 || numPoints > maxNumPoints || numCheckers < 0 || numCheckers > maxNumCheckers {
+		panic(fmt.Sprintf("bad database size: %d checkers on %d points", numCheckers, numPoints))
+	}
+	db := &OneSided{NumPoints: numPoints, NumCheckers: numCheckers}
+	n := numPositions(numPoints, numCheckers)
+	homes := allHomes(numPoints, numCheckers)
+	expected := make([]float64, n)
+	distributions := make([][]float64, n)
+	for _, h := range homes {
+		i := db.index(h)
+		if h.NumCheckers() == 0 {
+			distributions[i] = []float64{1}
+			continue
+		}
+		var dist []float64
+		e := 1.0
+		for _, wr := range brd.AllRolls() {
+			best := -1
+			h.successors(wr.Roll, func(leaf Home) {
+				if j := db.index(leaf); best < 0 || expected[j] < expected[best] {
+					best = j
+				}
+			})
+			p := wr.Probability()
+			e += p * expected[best]
+			for len(dist) < len(distributions[best])+1 {
+				dist = append(dist, 0)
+			}
+			for k, q := range distributions[best] {
+				dist[k+1] += p * q
+			}
+		}
+		expected[i] = e
+		distributions[i] = dist
+	}
+	db.entries = make([]entry, n)
+	for i := range db.entries {
+		db.entries[i] = newEntry(expected[i], distributions[i])
+	}
+	return db
+}
+
+func newEntry(expected float64, dist []float64) entry {
+	e := entry{expectedRolls: float32(expected)}
+	lo, hi := len(dist), 0
+	masses := make([]uint16, len(dist))
+	for k, p := range dist {
+		if masses[k] = uint16(p*65535 + 0.5); masses[k] > 0 {
+			if k < lo {
+				lo = k
+			}
+			hi = k + 1
+		}
+	}
+	if lo < hi {
+		e.first = uint8(lo)
+		e.masses = masses[lo:hi]
+	}
+	return e
+}
+
+// Calls visit with each position that playing roll can give, perhaps more
+// than once.
+func (h Home) successors(roll brd.Roll, visit func(Home)) {
+	d0, d1 := int(roll[0]), int(roll[1])
+	if d0 == d1 {
+		h.doublet(d0, 4, visit)
+		return
+	}
+	h.plays(d0, func(mid Home) { mid.plays(d1, visit) })
+	h.plays(d1, func(mid Home) { mid.plays(d0, visit) })
+}
+
+func (h Home) farthest() int {
+	for i := 5; i >= 0; i-- {
+		if h[i] > 0 {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Plays a checker on point from, a point number in [1, 6], die pips.
+func (h Home) move(from, die int) Home {
+	h[from-1]--
+	if from > die {
+		h[from-die-1]++
+	}
+	return h
+}
+
+// Calls visit with each position that playing a single die can give. A die
+// larger than the farthest checker bears that checker off.
+func (h Home) plays(die int, visit func(Home)) {
+	farthest := h.farthest()
+	if farthest == 0 {
+		visit(h)
+		return
+	}
+	for i := 1; i <= farthest; i++ {
+		if h[i-1] == 0 || (i < die && i != farthest) {
+			continue
+		}
+		visit(h.move(i, die))
+	}
+}
+
+// Plays die n times. Moving checkers in a different order gives the same
+// position, so we insist that each checker moved starts no farther away than
+// the previous one did.
+func (h Home) doublet(die, n int, visit func(Home)) {
+	var recurse func(h Home, n, maxStart int)
+	recurse = func(h Home, n, maxStart int) {
+		farthest := h.farthest()
+		if n == 0 || farthest == 0 {
+			visit(h)
+			return
+		}
+		for i := 1; i <= maxStart && i <= farthest; i++ {
+			if h[i-1] == 0 || (i < die && i != farthest) {
+				continue
+			}
+			recurse(h.move(i, die), n-1, i)
+		}
+	}
+	recurse(h, n, 6)
+}
+
+var defaultDB struct {
+	sync.Once
+	db *OneSided
+}
+
+// The database of fifteen checkers on six points, generated on first use
+// unless SetDefault() came first.
+func Default() *OneSided {
+	defaultDB.Do(func() {
+		defaultDB.db = Generate(maxNumPoints, maxNumCheckers)
+	})
+	return defaultDB.db
+}
+
+// Makes db, e.g. one loaded with Read(), the Default(). Call it before anyone
+// calls Default(). db must hold fifteen checkers on six points.
+func SetDefault(db *OneSided) {
+	if db.NumPoints != maxNumPoints || db.NumCheckers != maxNumCheckers {
+		panic(fmt.Sprintf("the default database has %d checkers on %d points", maxNumCheckers, maxNumPoints))
+	}
+	defaultDB.Do(func() {
+		defaultDB.db = db
+	})
+}
@@ -0,0 +1,264 @@
+package bearoff
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+func TestIndex(t *testing.T) {
+	db := &OneSided{NumPoints: 3, NumCheckers: 4}
+	n := numPositions(db.NumPoints, db.NumCheckers)
+	if n != 35 {
+		t.Fatalf("n=%d", n)
+	}
+	seen := make([]bool, n)
+	for a := 0; a <= 4; a++ {
+		for b := 0; a+b <= 4; b++ {
+			for c := 0; a+b+c <= 4; c++ {
+				h := Home{uint8(a), uint8(b), uint8(c)}
+				i := db.index(h)
+				if i < 0 || i >= n || seen[i] {
+					t.Fatalf("index(%v)=%d", h, i)
+				}
+				seen[i] = true
+			}
+		}
+	}
+}
+
+func TestOneSided(t *testing.T) {
+	type example struct {
+		Home          Home
+		ExpectedRolls float64
+		OffIn         []string // OffIn(Home, 0), OffIn(Home, 1), ...
+	}
+	examples := [...]example{
+		example{Home{}, 0, []string{"1.000"}},
+		example{Home{1}, 1, []string{"0.000", "1.000"}},
+		// Any roll with a 1 but <1 1> leaves a checker behind.
+		example{Home{0, 2}, 1 + 10.0/36, []string{"0.000", "0.722", "1.000"}},
+		// <1 2>, <1 3>, <1 4>, <2 3>, and <1 1> fail to bear off a checker
+		// on the six point, and any roll finishes the job.
+		example{Home{0, 0, 0, 0, 0, 1}, 1.25, []string{"0.000", "0.750", "1.000"}},
+		example{Home{4}, 1 + 30.0/36, []string{"0.000", "0.167", "1.000"}},
+	}
+	db := Default()
+	for i, ex := range examples {
+		if x := db.ExpectedRolls(ex.Home); math.Abs(x-ex.ExpectedRolls) > 1e-5 {
+			t.Errorf("i=%d expected rolls=%v", i, x)
+		}
+		for n, expected := range ex.OffIn {
+			if x := fmt.Sprintf("%.3f", db.OffIn(ex.Home, n)); x != expected {
+				t.Errorf("i=%d OffIn(%d)=%v", i, n, x)
+			}
+		}
+		var sum, mean float64
+		for n, p := range db.Distribution(ex.Home) {
+			sum += p
+			mean += float64(n) * p
+		}
+		if math.Abs(sum-1) > 1e-3 || math.Abs(mean-db.ExpectedRolls(ex.Home)) > 1e-2 {
+			t.Errorf("i=%d sum=%v mean=%v", i, sum, mean)
+		}
+	}
+	// Fifteen on the six point need more than eleven rolls even with
+	// doublets galore.
+	if x := db.ExpectedRolls(Home{0, 0, 0, 0, 0, 15}); x < 11 || x > 14 {
+		t.Errorf("expected rolls=%v", x)
+	}
+	// A smaller database agrees where they overlap.
+	small := Generate(3, 4)
+	for _, h := range [...]Home{Home{}, Home{4}, Home{1, 2, 1}, Home{0, 0, 4}} {
+		if x, y := small.ExpectedRolls(h), db.ExpectedRolls(h); x != y {
+			t.Errorf("%v: %v vs. %v", h, x, y)
+		}
+		if x, y := fmt.Sprint(small.Distribution(h)), fmt.Sprint(db.Distribution(h)); x != y {
+			t.Errorf("%v: %v vs. %v", h, x, y)
+		}
+	}
+	if small.Contains(Home{0, 0, 0, 1}) || small.Contains(Home{5}) || !small.Contains(Home{1, 1, 2}) {
+		t.Errorf("Contains")
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	db := Generate(4, 6)
+	var buf bytes.Buffer
+	if err := db.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := Read(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.NumPoints != 4 || read.NumCheckers != 6 || len(read.entries) != len(db.entries) {
+		t.Fatalf("read %d checkers on %d points", read.NumCheckers, read.NumPoints)
+	}
+	if x, y := fmt.Sprint(read.entries), fmt.Sprint(db.entries); x != y {
+		t.Errorf("read %v\nwrote %v", x, y)
+	}
+	if _, err := Read(bytes.NewReader([]byte("garbage"))); err == nil {
+		t.Errorf("read garbage")
+	}
+}
+
+func TestHomeOf(t *testing.T) {
+	b := brd.New(false)
+	b.Pips = brd.Points28{}
+	b.Pips[1].Reset(2, brd.Red)
+	b.Pips[6].Reset(3, brd.Red)
+	b.Pips[brd.BorneOffRedPip].Reset(10, brd.Red)
+	b.Pips[19].Reset(14, brd.White)
+	b.Pips[7].Reset(1, brd.White)
+	if iv := b.Invalidity(brd.IgnoreRollValidity); iv != "" {
+		t.Fatal(iv)
+	}
+	if h, ok := HomeOf(b, brd.Red); !ok || h.String() != "[2 0 0 0 0 3]" || h.PipCount() != 20 {
+		t.Errorf("h=%v ok=%v", h, ok)
+	}
+	if h, ok := HomeOf(b, brd.White); ok {
+		t.Errorf("h=%v", h)
+	}
+}
+
+func TestTwoSided(t *testing.T) {
+	type example struct {
+		OnRoll, Other Home
+		Cube          CubeState
+		Win           string
+		Equity        string
+		Action        string
+	}
+	examples := [...]example{
+		// Doubling can't improve on a sure win.
+		example{Home{1}, Home{0, 0, 0, 0, 0, 4}, Centered, "1.0000", "+1.0000", "no double/pass"},
+		// The classic last roll: a take, just barely.
+		example{Home{0, 0, 0, 0, 0, 1}, Home{1}, Centered, "0.7500", "+1.0000", "double/take"},
+		example{Home{0, 0, 0, 0, 0, 1}, Home{1}, Unavailable, "0.7500", "+0.5000", "no double/take"},
+		example{Home{0, 0, 0, 0, 0, 1}, Home{1}, Dead, "0.7500", "+0.5000", "no double/take"},
+		example{Home{0, 2}, Home{0, 2}, Centered, "0.7994", "+0.9506", "double/take"},
+		example{Home{0, 2}, Home{0, 2}, Owned, "0.7994", "+0.9506", "double/take"},
+		example{Home{0, 0, 0, 0, 2}, Home{0, 0, 0, 0, 2}, Centered, "0.8001", "+1.0000", "double/pass"},
+		example{Home{0, 0, 0, 1, 1, 1}, Home{0, 0, 1, 1, 1}, Centered, "0.5720", "+0.1447", "no double/take"},
+	}
+	db := GenerateTwoSided(6, 4)
+	for i, ex := range examples {
+		if x := fmt.Sprintf("%.4f", db.WinProbability(ex.OnRoll, ex.Other)); x != ex.Win {
+			t.Errorf("i=%d win=%v", i, x)
+		}
+		if x := fmt.Sprintf("%+.4f", db.Equity(ex.OnRoll, ex.Other, ex.Cube)); x != ex.Equity {
+			t.Errorf("i=%d equity=%v", i, x)
+		}
+		double, take := db.CubeAction(ex.OnRoll, ex.Other, ex.Cube)
+		action := "no double/"
+		if double {
+			action = "double/"
+		}
+		if take {
+			action += "take"
+		} else {
+			action += "pass"
+		}
+		if action != ex.Action {
+			t.Errorf("i=%d action=%v", i, action)
+		}
+	}
+	var buf bytes.Buffer
+	if err := db.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadTwoSided(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.NumPoints != 6 || read.NumCheckers != 4 || fmt.Sprint(read.entries) != fmt.Sprint(db.entries) {
+		t.Errorf("read %d checkers on %d points", read.NumCheckers, read.NumPoints)
+	}
+	// A header claiming fifteen checkers would have us allocate tens of
+	// gigabytes.
+	buf.Reset()
+	zw, _ := flate.NewWriter(&buf, flate.BestSpeed)
+	zw.Write([]byte(twoSidedMagic + "\x06\x0f"))
+	zw.Close()
+	if _, err := ReadTwoSided(&buf); err == nil || err.Error() != "bad database size: 15 checkers on 6 points" {
+		t.Errorf("%v", err)
+	}
+}
+
+// Red has red on its own points and White has white, and each has borne off
+// the rest of its fifteen checkers.
+func newBearoff(roller brd.Checker, red, white Home) *brd.Board {
+	b := brd.New(false)
+	b.Roller = roller
+	b.Pips = brd.Points28{}
+	for i := range red {
+		b.Pips[i+1].Reset(int(red[i]), brd.Red)
+		b.Pips[24-i].Reset(int(white[i]), brd.White)
+	}
+	b.Pips[brd.BorneOffRedPip].Reset(15-red.NumCheckers(), brd.Red)
+	b.Pips[brd.BorneOffWhitePip].Reset(15-white.NumCheckers(), brd.White)
+	return b
+}
+
+func TestTwoSidedPlay(t *testing.T) {
+	db := GenerateTwoSided(6, 4)
+	fallback := func(choices []*brd.Board) []brd.AnalyzedBoard {
+		return []brd.AnalyzedBoard{brd.AnalyzedBoard{Board: choices[0]}}
+	}
+	chooser := db.Chooser(fallback)
+	b := newBearoff(brd.Red, Home{0, 0, 1, 0, 1, 1}, Home{0, 0, 0, 0, 0, 2})
+	b.Roll = brd.Roll{6, 1}
+	if iv := b.Invalidity(brd.EnforceRollValidity); iv != "" {
+		t.Fatal(iv)
+	}
+	if !db.Covers(b) {
+		t.Fatalf("%v", b)
+	}
+	choices := b.LegalContinuations()
+	analyzed := chooser(choices)
+	if len(analyzed) != len(choices) {
+		t.Fatalf("%v", analyzed)
+	}
+	// Playing 3=>2 rather than 5=>4 leaves fewer rolls that miss.
+	if x := analyzed[0].String(); x != "{r after playing   61; !dbl; 1: 2:r 3: 4: 5:r 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19:WW 20: 21: 22: 23: 24:, 13 W off, 13 r off} (win 57.1% equity +0.219 cube centered)" {
+		t.Errorf("choice was %v", x)
+	}
+	b = newBearoff(brd.Red, Home{0, 0, 0, 0, 0, 1}, Home{1})
+	if !db.OfferDouble(nil)(b) || !db.AcceptDouble(nil)(b) {
+		t.Errorf("double/take")
+	}
+	b.MatchScore.Goal = 5
+	if db.OfferDouble(nil)(b) || !db.AcceptDouble(nil)(b) {
+		t.Errorf("the nil fallbacks never double and always take")
+	}
+	b = newBearoff(brd.Red, Home{0, 0, 0, 0, 0, 1}, Home{0, 0, 0, 0, 0, 5})
+	if db.Covers(b) {
+		t.Errorf("five checkers are too many")
+	}
+	if x := chooser([]*brd.Board{b})[0].String(); x != b.String() {
+		t.Errorf("fallback gave %v", x)
+	}
+	b = newBearoff(brd.Red, Home{0, 0, 0, 0, 0, 1}, Home{1})
+	b.Pips[brd.BorneOffWhitePip] = 0
+	b.Pips[19].Reset(14, brd.White)
+	if db.Covers(b) {
+		t.Errorf("a gammon is possible")
+	}
+}
+
+func BenchmarkTwoSidedCovers(b *testing.B) {
+	db := GenerateTwoSided(6, 4)
+	board := newBearoff(brd.Red, Home{0, 0, 1, 0, 1, 1}, Home{0, 0, 0, 0, 0, 2})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !db.Covers(board) {
+			b.Fatal("not covered")
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package bearoff
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The file format, DEFLATE-compressed as a whole:
+//
+//	the 8-byte magic number "gbgbo1s\n"
+//	NumPoints and NumCheckers, one byte each
+//	then, for each position in order of index:
+//	  the expected number of rolls, a little-endian IEEE 754 float32
+//	  the fewest rolls the position can need, one byte
+//	  the number of rolls counts that follow, one byte
+//	  for each of those counts of rolls, starting with the fewest, the
+//	  probability of needing exactly that many rolls times 65535, a
+//	  little-endian uint16
+//
+// The full fifteen checkers on six points comes to about a megabyte.
+const oneSidedMagic = "gbgbo1s\n"
+
+// The two-sided file format, DEFLATE-compressed as a whole:
+//
+//	the 8-byte magic number "gbgbt2s\n"
+//	NumPoints and NumCheckers, one byte each
+//	then, for each position in order of index(onRoll)*numPositions +
+//	index(other), four little-endian IEEE 754 float32s:
+//	  the probability that the player on roll wins
+//	  its equity if it doesn't double with the cube Centered, Owned, and
+//	  Unavailable
+const twoSidedMagic = "gbgbt2s\n"
+
+func (db *OneSided) Write(w io.Writer) error {
+	zw, err := flate.NewWriter(w, flate.BestCompression)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(zw)
+	bw.WriteString(oneSidedMagic)
+	bw.WriteByte(byte(db.NumPoints))
+	bw.WriteByte(byte(db.NumCheckers))
+	var buf [4]byte
+	for _, e := range db.entries {
+		binary.LittleEndian.PutUint32(buf[:], math.Float32bits(e.expectedRolls))
+		bw.Write(buf[:])
+		bw.WriteByte(e.first)
+		bw.WriteByte(byte(len(e.masses)))
+		for _, m := range e.masses {
+			binary.LittleEndian.PutUint16(buf[:2], m)
+			bw.Write(buf[:2])
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// The inverse of Write().
+func Read(r io.Reader) (*OneSided, error) {
+	br := bufio.NewReader(flate.NewReader(r))
+	header := make([]byte, len(oneSidedMagic)+2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(oneSidedMagic)]) != oneSidedMagic {
+		return nil, errors.New("not a one-sided bearoff database")
+	}
+	db := &OneSided{NumPoints: int(header[len(oneSidedMagic)]), NumCheckers: int(header[len(oneSidedMagic)+1])}
+	if db.NumPoints < 1 || db.NumPoints > maxNumPoints || db.NumCheckers > maxNumCheckers {
+		return nil, fmt.Errorf("bad database size: %d checkers on %d points", db.NumCheckers, db.NumPoints)
+	}
+	db.entries = make([]entry, numPositions(db.NumPoints, db.NumCheckers))
+	var buf [6]byte
+	for i := range db.entries {
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			return nil, fmt.Errorf("position %d: %v", i, err)
+		}
+		e := &db.entries[i]
+		e.expectedRolls = math.Float32frombits(binary.LittleEndian.Uint32(buf[:4]))
+		e.first = buf[4]
+		if n := int(buf[5]); n > 0 {
+			raw := make([]byte, 2*n)
+			if _, err := io.ReadFull(br, raw); err != nil {
+				return nil, fmt.Errorf("position %d: %v", i, err)
+			}
+			e.masses = make([]uint16, n)
+			for k := range e.masses {
+				e.masses[k] = binary.LittleEndian.Uint16(raw[2*k:])
+			}
+		}
+	}
+	return db, nil
+}
+
+func (db *TwoSided) Write(w io.Writer) error {
+	zw, err := flate.NewWriter(w, flate.BestCompression)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(zw)
+	bw.WriteString(twoSidedMagic)
+	bw.WriteByte(byte(db.NumPoints))
+	bw.WriteByte(byte(db.NumCheckers))
+	var buf [16]byte
+	for _, e := range db.entries {
+		binary.LittleEndian.PutUint32(buf[:4], math.Float32bits(e.win))
+		for c, x := range e.noDouble {
+			binary.LittleEndian.PutUint32(buf[4+4*c:], math.Float32bits(x))
+		}
+		bw.Write(buf[:])
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// The inverse of TwoSided.Write().
+func ReadTwoSided(r io.Reader) (*TwoSided, error) {
+	br := bufio.NewReader(flate.NewReader(r))
+	header := make([]byte, len(twoSidedMagic)+2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(twoSidedMagic)]) != twoSidedMagic {
+		return nil, errors.New("not a two-sided bearoff database")
+	}
+	db := &TwoSided{NumPoints: int(header[len(twoSidedMagic)]), NumCheckers: int(header[len(twoSidedMagic)+1])}
+	if db.NumPoints < 1 || db.NumPoints > maxNumPoints || db.NumCheckers > maxTwoSidedCheckers {
+		return nil, fmt.Errorf("bad database size: %d checkers on %d points", db.NumCheckers, db.NumPoints)
+	}
+	n := numPositions(db.NumPoints, db.NumCheckers)
+	db.entries = make([]twoSidedEntry, n*n)
+	var buf [16]byte
+	for i := range db.entries {
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			return nil, fmt.Errorf("position %d: %v", i, err)
+		}
+		e := &db.entries[i]
+		e.win = math.Float32frombits(binary.LittleEndian.Uint32(buf[:4]))
+		for c := range e.noDouble {
+			e.noDouble[c] = math.Float32frombits(binary.LittleEndian.Uint32(buf[4+4*c:]))
+		}
+	}
+	return db, nil
+}
@@ -0,0 +1,121 @@
+package bearoff
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// Whether db has the answers for b: a Standard game in which both players are
+// bearing off within db and nobody can be gammoned.
+func (db *TwoSided) Covers(b *brd.Board) bool {
+	if b.Variant != brd.Standard || b.Bonus != brd.ZeroDie || b.RollAgain {
+		return false
+	}
+	for _, player := range [2]brd.Checker{brd.White, brd.Red} {
+		h, ok := HomeOf(b, player)
+		if !ok || !db.oneSided().Contains(h) {
+			return false
+		}
+		if !b.Rules.NoGammons && b.Perspective(player).Mine[0] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// The CubeState that db's equities should use for player. In a match we play
+// for the win alone, so the cube is Dead there as it is once the Rules'
+// MaxCube is reached.
+//
+// TODO(chandler37): Use match equities to give the cube its due in a match.
+func cubeFor(b *brd.Board, player brd.Checker) CubeState {
+	if b.MatchScore.Goal > 0 || (b.Rules.MaxCube != 0 && 2*b.Stakes > int(b.Rules.MaxCube)) {
+		return Dead
+	}
+	return CubeStateOf(b, player)
+}
+
+// The worth of a Board to the player who just moved, i.e., its Roller. It is
+// a brd.Analysis.
+type Evaluation struct {
+	Win    float64   // the probability of winning
+	Equity float64   // per unit of cube
+	Cube   CubeState // the opponent's, which is on roll
+}
+
+func (e Evaluation) Summary() string {
+	return fmt.Sprintf("win %.1f%% equity %+.3f cube %v", 100*e.Win, e.Equity, e.Cube)
+}
+
+// b must be Covered.
+func (db *TwoSided) evaluate(b *brd.Board) Evaluation {
+	me, them := b.Roller, b.Roller.OtherColor()
+	mine, _ := HomeOf(b, me)
+	theirs, _ := HomeOf(b, them)
+	cube := cubeFor(b, them)
+	if mine.NumCheckers() == 0 {
+		return Evaluation{1, 1, cube}
+	}
+	return Evaluation{
+		Win:    1 - db.WinProbability(theirs, mine),
+		Equity: -db.Equity(theirs, mine, cube),
+		Cube:   cube,
+	}
+}
+
+// A brd.Chooser that plays perfectly, by the money-game equity or, in a match,
+// the chances of winning, whenever db Covers every choice. Otherwise it
+// defers to fallback.
+func (db *TwoSided) Chooser(fallback brd.Chooser) brd.Chooser {
+	return func(choices []*brd.Board) []brd.AnalyzedBoard {
+		for _, b := range choices {
+			if !db.Covers(b) {
+				return fallback(choices)
+			}
+		}
+		result := make([]brd.AnalyzedBoard, len(choices))
+		for i, b := range choices {
+			result[i] = brd.AnalyzedBoard{Board: b, Analysis: db.evaluate(b)}
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			x, y := result[i].Analysis.(Evaluation), result[j].Analysis.(Evaluation)
+			if x.Equity != y.Equity {
+				return x.Equity > y.Equity
+			}
+			return x.Win > y.Win
+		})
+		return result
+	}
+}
+
+// An offerDouble for brd.Board.TakeTurn() that doubles exactly when it should
+// in a money game that db Covers. Otherwise it defers to fallback, which may be
+// nil for never doubling.
+func (db *TwoSided) OfferDouble(fallback func(*brd.Board) bool) func(*brd.Board) bool {
+	return func(b *brd.Board) bool {
+		if !db.Covers(b) || cubeFor(b, b.Roller) == Dead {
+			return fallback != nil && fallback(b)
+		}
+		mine, _ := HomeOf(b, b.Roller)
+		theirs, _ := HomeOf(b, b.Roller.OtherColor())
+		double, _ := db.CubeAction(mine, theirs, CubeStateOf(b, b.Roller))
+		return double
+	}
+}
+
+// An acceptDouble for brd.Board.TakeTurn() that takes exactly when it should in
+// a money game that db Covers. Otherwise it defers to fallback, which may be
+// nil for always taking.
+func (db *TwoSided) AcceptDouble(fallback func(*brd.Board) bool) func(*brd.Board) bool {
+	return func(b *brd.Board) bool {
+		if !db.Covers(b) || cubeFor(b, b.Roller) == Dead {
+			return fallback == nil || fallback(b)
+		}
+		mine, _ := HomeOf(b, b.Roller)
+		theirs, _ := HomeOf(b, b.Roller.OtherColor())
+		_, take := db.CubeAction(mine, theirs, CubeStateOf(b, b.Roller))
+		return take
+	}
+}
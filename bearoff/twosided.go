@@ -0,0 +1,245 @@
+package bearoff
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// Who may turn the doubling cube, from the point of view of the player on
+// roll.
+type CubeState uint8
+
+const (
+	Centered    CubeState = iota // either player may double
+	Owned                        // only the player on roll may double
+	Unavailable                  // only the opponent may double
+	Dead                         // nobody may double, e.g. in the Crawford game
+)
+
+func (c CubeState) String() string {
+	switch c {
+	case Centered:
+		return "centered"
+	case Owned:
+		return "owned"
+	case Unavailable:
+		return "unavailable"
+	case Dead:
+		return "dead"
+	default:
+		return fmt.Sprintf("CubeState(%d)", int(c))
+	}
+}
+
+// The same cube from the opponent's point of view.
+func (c CubeState) flip() CubeState {
+	switch c {
+	case Owned:
+		return Unavailable
+	case Unavailable:
+		return Owned
+	default:
+		return c
+	}
+}
+
+// The CubeState for player, per b.WhiteCanDouble and b.RedCanDouble. Ignores
+// the MatchScore and the Rules' MaxCube.
+func CubeStateOf(b *brd.Board, player brd.Checker) CubeState {
+	mine, theirs := b.WhiteCanDouble, b.RedCanDouble
+	if player == brd.Red {
+		mine, theirs = theirs, mine
+	}
+	switch {
+	case mine && theirs:
+		return Centered
+	case mine:
+		return Owned
+	case theirs:
+		return Unavailable
+	default:
+		return Dead
+	}
+}
+
+// A two-sided bearoff database: for every pair of Homes within NumCheckers
+// checkers on NumPoints points, the exact chances and money-game equities of
+// the player on roll, both sides playing perfectly.
+//
+// The equities ignore gammons, so they're exact only once both players have
+// borne off a checker (or if the Rules say there are no gammons).
+type TwoSided struct {
+	NumPoints   int
+	NumCheckers int
+	entries     []twoSidedEntry // indexed by index(onRoll)*numPositions + index(other)
+	size        struct {
+		sync.Once
+		db *OneSided
+	}
+}
+
+// The player on roll's chance of winning, playing to maximize it, and its
+// equity per unit of cube if it doesn't double now, for each CubeState but
+// Dead.
+type twoSidedEntry struct {
+	win      float32
+	noDouble [3]float32
+}
+
+// Equity per unit of cube after the player on roll makes its cube decision
+// and its opponent responds.
+func (e *twoSidedEntry) equity(cube CubeState) float64 {
+	switch cube {
+	case Dead:
+		return 2*float64(e.win) - 1
+	case Unavailable:
+		return float64(e.noDouble[Unavailable])
+	}
+	// If the opponent takes, the player is on roll at twice the stakes with
+	// the cube Unavailable.
+	return math.Max(float64(e.noDouble[cube]), e.doubled())
+}
+
+func (e *twoSidedEntry) doubled() float64 {
+	return math.Min(2*float64(e.noDouble[Unavailable]), 1)
+}
+
+// Whether both onRoll and other are in the database.
+func (db *TwoSided) Contains(onRoll, other Home) bool {
+	return db.oneSided().Contains(onRoll) && db.oneSided().Contains(other)
+}
+
+// A OneSided of the same size, without entries, so that we can ask it what
+// it Contains(). We make it once rather than on every lookup.
+func (db *TwoSided) oneSided() *OneSided {
+	db.size.Do(func() {
+		db.size.db = &OneSided{NumPoints: db.NumPoints, NumCheckers: db.NumCheckers}
+	})
+	return db.size.db
+}
+
+func (db *TwoSided) lookup(onRoll, other Home) *twoSidedEntry {
+	if !db.Contains(onRoll, other) {
+		panic(fmt.Sprintf("%v vs. %v is not in a database of %d checkers on %d points", onRoll, other, db.NumCheckers, db.NumPoints))
+	}
+	n := numPositions(db.NumPoints, db.NumCheckers)
+	return &db.entries[rank(db.NumPoints, onRoll)*n+rank(db.NumPoints, other)]
+}
+
+// The probability that the player on roll with onRoll wins against other,
+// ignoring the cube. Both must be in the database.
+func (db *TwoSided) WinProbability(onRoll, other Home) float64 {
+	return float64(db.lookup(onRoll, other).win)
+}
+
+// The money-game equity of the player on roll with onRoll against other, per
+// unit of cube, with optimal cube action and checker play. Both must be in the
+// database.
+func (db *TwoSided) Equity(onRoll, other Home, cube CubeState) float64 {
+	return db.lookup(onRoll, other).equity(cube)
+}
+
+// Whether the player on roll with onRoll against other should double in a
+// money game, and whether other should take if it did. Both must be in the
+// database.
+func (db *TwoSided) CubeAction(onRoll, other Home, cube CubeState) (double, take bool) {
+	e := db.lookup(onRoll, other)
+	take = 2*e.noDouble[Unavailable] <= 1
+	if cube == Centered || cube == Owned {
+		double = e.doubled() > float64(e.noDouble[cube])
+	}
+	return
+}
+
+// Builds a two-sided database, which holds the square of the number of Homes
+// in the one-sided database of the same size. Six checkers on six points
+// takes about a second. There can be at most seven checkers.
+func GenerateTwoSided(numPoints, numCheckers int) *TwoSided {
+	if numPoints < 1 || numPoints > maxNumPoints || numCheckers < 0 || numCheckers > maxTwoSidedCheckers {
+		panic(fmt.Sprintf("bad database size: %d checkers on %d points", numCheckers, numPoints))
+	}
+	db := &TwoSided{NumPoints: numPoints, NumCheckers: numCheckers}
+	n := numPositions(numPoints, numCheckers)
+	homes := allHomes(numPoints, numCheckers)
+	rolls := brd.AllRolls()
+	// successors[i][r] are the distinct ranks of the Homes that the Home
+	// ranked i can play rolls[r] to.
+	successors := make([][len(rolls)][]int32, n)
+	for _, h := range homes {
+		i := rank(numPoints, h)
+		for r, wr := range rolls {
+			var s []int32
+			h.successors(wr.Roll, func(leaf Home) {
+				j := int32(rank(numPoints, leaf))
+				for _, k := range s {
+					if k == j {
+						return
+					}
+				}
+				s = append(s, j)
+			})
+			successors[i][r] = s
+		}
+	}
+	byPipCount := make([][]int, 6*numCheckers+1)
+	for _, h := range homes {
+		byPipCount[h.PipCount()] = append(byPipCount[h.PipCount()], rank(numPoints, h))
+	}
+	db.entries = make([]twoSidedEntry, n*n)
+	// Playing lowers the total pip count, so we go in order of it.
+	for total := 0; total < 2*len(byPipCount)-1; total++ {
+		for pips := 0; pips <= total; pips++ {
+			if pips >= len(byPipCount) || total-pips >= len(byPipCount) {
+				continue
+			}
+			for _, i := range byPipCount[pips] {
+				for _, j := range byPipCount[total-pips] {
+					db.entries[i*n+j] = db.generateEntry(i, j, n, successors, rolls)
+				}
+			}
+		}
+	}
+	return db
+}
+
+func (db *TwoSided) generateEntry(i, j, n int, successors [][21][]int32, rolls [21]brd.WeightedRoll) (result twoSidedEntry) {
+	const empty = 0 // the rank of the empty Home
+	if i == empty {
+		return twoSidedEntry{1, [3]float32{1, 1, 1}}
+	}
+	if j == empty {
+		return twoSidedEntry{0, [3]float32{-1, -1, -1}}
+	}
+	var win float64
+	var noDouble [3]float64
+	for r, wr := range rolls {
+		bestWin := -1.0
+		bestNoDouble := [3]float64{-2, -2, -2}
+		for _, s := range successors[i][r] {
+			if s == empty {
+				bestWin = 1
+				bestNoDouble = [3]float64{1, 1, 1}
+				break
+			}
+			// The opponent is on roll.
+			e := &db.entries[j*n+int(s)]
+			bestWin = math.Max(bestWin, 1-float64(e.win))
+			for c := range bestNoDouble {
+				bestNoDouble[c] = math.Max(bestNoDouble[c], -e.equity(CubeState(c).flip()))
+			}
+		}
+		p := wr.Probability()
+		win += p * bestWin
+		for c := range noDouble {
+			noDouble[c] += p * bestNoDouble[c]
+		}
+	}
+	result.win = float32(win)
+	for c := range noDouble {
+		result.noDouble[c] = float32(noDouble[c])
+	}
+	return
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"strconv"
@@ -12,6 +13,9 @@ import (
 
 	"github.com/chandler37/gobackgammon/ai"
 	"github.com/chandler37/gobackgammon/brd"
+	ds "github.com/chandler37/gobackgammon/dataset"
+	"github.com/chandler37/gobackgammon/nn"
+	"github.com/chandler37/gobackgammon/rollout"
 )
 
 var seedOverride = flag.Int64(
@@ -39,6 +43,96 @@ var random = flag.Bool(
 	false,
 	"Use an insane dart thrower instead of a conservative player.")
 
+var plies = flag.Uint(
+	"plies",
+	0,
+	"How many rolls ahead the computer looks when it chooses a move. Each one beyond 2 makes it some twenty times slower.")
+
+var variant = flag.String(
+	"variant",
+	brd.Standard.String(),
+	"Which ruleset to play: Standard, LongNardy, or AceyDeucey")
+
+var noCrawford = flag.Bool(
+	"noCrawford",
+	false,
+	"House rule: In a match, play without the Crawford rule.")
+
+var oneDieEach = flag.Bool(
+	"oneDieEach",
+	false,
+	"House rule: Each player rolls one die to decide who goes first.")
+
+var automaticDoubles = flag.Uint(
+	"automaticDoubles",
+	0,
+	"House rule: How many times, at most 30, ties on the opening roll may double the stakes.")
+
+var maxCube = flag.Uint(
+	"maxCube",
+	0,
+	"House rule: The highest the stakes may go, or zero for no limit.")
+
+var noGammons = flag.Bool(
+	"noGammons",
+	false,
+	"House rule: Gammons and backgammons count as single games.")
+
+var rolloutWorker = flag.Bool(
+	"rolloutWorker",
+	false,
+	"Instead of playing, serve distributed rollouts over stdin and stdout.")
+
+var rolloutListen = flag.String(
+	"rolloutListen",
+	"",
+	"Instead of playing, serve distributed rollouts to coordinators that connect to this TCP address, e.g., :3737")
+
+var train = flag.String(
+	"train",
+	"",
+	"Instead of playing, train a neural network by self-play, saving checkpoints in this directory and resuming from the latest. Pass the same -seed each time to make it reproducible.")
+
+var trainGames = flag.Int(
+	"trainGames",
+	100000,
+	"With -train, how many games of self-play in all")
+
+var hidden = flag.Int(
+	"hidden",
+	40,
+	"With -train, how many hidden units a new neural network has")
+
+var dataset = flag.String(
+	"dataset",
+	"",
+	"Instead of playing, play games of self-play and write positions from them, with their features and outcomes, to this file")
+
+var datasetFormat = flag.String(
+	"datasetFormat",
+	"csv",
+	"With -dataset, one of "+strings.Join(ds.Formats, ", "))
+
+var datasetGames = flag.Int(
+	"datasetGames",
+	1000,
+	"With -dataset, how many games")
+
+var datasetChooser = flag.String(
+	"datasetChooser",
+	"conservative",
+	"With -dataset, how both players choose their plays: racer, random, or conservative with -plies of foresight")
+
+var datasetSample = flag.Float64(
+	"datasetSample",
+	0.1,
+	"With -dataset, the chance of recording each position")
+
+var datasetRolloutTrials = flag.Int(
+	"datasetRolloutTrials",
+	0,
+	"With -dataset, if positive, roll out each recorded position this many times")
+
 var automaticallyAcceptTheOnlyChoice = flag.Bool(
 	"automaticallyAcceptTheOnlyChoice",
 	false,
@@ -48,13 +142,35 @@ func matchIsOver(score brd.Score) bool {
 	return score.RedScore >= score.Goal || score.WhiteScore >= score.Goal
 }
 
+func newBoard() *brd.Board {
+	v, err := brd.ParseVariant(*variant)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
+	}
+	if *automaticDoubles > brd.MaxAutomaticDoubles || *maxCube > 65535 {
+		fmt.Fprintf(os.Stderr, "-automaticDoubles or -maxCube is too large\n")
+		os.Exit(2)
+	}
+	rules := brd.Rules{
+		NoCrawfordRule:   *noCrawford,
+		AutomaticDoubles: uint8(*automaticDoubles),
+		NoGammons:        *noGammons,
+		MaxCube:          uint16(*maxCube),
+	}
+	if *oneDieEach {
+		rules.Opening = brd.OneDieEach
+	}
+	return brd.NewGame(v, rules, false)
+}
+
 func playAuto() {
 	score := brd.Score{}
 	if *matchGoal > 0 {
 		score.Goal = int(*matchGoal)
 	}
 	for {
-		board := brd.New(false)
+		board := newBoard()
 		board.SetScore(score)
 		fmt.Printf(
 			"The official backgammon rules dictate the following starting configuration\nfor a game against Red ('r') and White ('W')\nand we've randomly chosen who goes first and with which roll:\n%v\n",
@@ -65,7 +181,7 @@ func playAuto() {
 			numBoards++
 			fmt.Printf("%v\n", b.String())
 		}
-		chooser := ai.MakePlayerConservative(0, nil)
+		chooser := ai.MakePlayerConservative(uint64(*plies), nil)
 		if *random {
 			chooser = ai.PlayerRandom
 		}
@@ -83,19 +199,83 @@ func playAuto() {
 	}
 }
 
+func writeDataset(seed int64) error {
+	chooser, err := rollout.ChooserSettings{Name: *datasetChooser, Plies: int(*plies)}.Chooser()
+	if err != nil {
+		return err
+	}
+	settings := ds.Settings{Games: *datasetGames, White: chooser, Red: chooser, Seed: seed, Sample: *datasetSample}
+	if *datasetRolloutTrials > 0 {
+		settings.Rollout = &rollout.Settings{Trials: *datasetRolloutTrials, White: chooser, Red: chooser, Workers: 1, Seed: seed}
+	}
+	f, err := os.Create(*dataset)
+	if err != nil {
+		return err
+	}
+	w, err := ds.NewWriter(*datasetFormat, f)
+	if err == nil {
+		err = ds.Generate(settings, w)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
 func main() {
 	flag.Parse()
 	seed := time.Now().UnixNano()
 	if *seedOverride > -1 {
 		seed = *seedOverride
 	}
-	fmt.Printf("rand.Seed(%v)\n", seed)
 	rand.Seed(seed)
+	if *rolloutWorker {
+		if err := rollout.Serve(struct {
+			io.Reader
+			io.Writer
+		}{os.Stdin, os.Stdout}); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *rolloutListen != "" {
+		fmt.Fprintf(os.Stderr, "%v\n", rollout.ListenAndServe(*rolloutListen))
+		os.Exit(1)
+	}
+	fmt.Printf("rand.Seed(%v)\n", seed)
+	if *train != "" {
+		settings := nn.TrainSettings{
+			Games:           *trainGames,
+			Lambda:          0.7,
+			Rate:            0.1,
+			Seed:            seed,
+			Dir:             *train,
+			CheckpointEvery: 1000,
+			BenchmarkGames:  200,
+			Report:          func(p nn.Progress) { fmt.Println(p.Summary()) },
+		}
+		if _, err := nn.Train(nn.New(*hidden, seed), settings); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *dataset != "" {
+		if err := writeDataset(seed); err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 	if *auto {
 		playAuto()
 		return
 	}
-	board := brd.New(false)
+	board := newBoard()
 	fmt.Printf(
 		"The official backgammon rules dictate the following starting configuration\nfor a game against Red ('r') and White ('W')\nand we've randomly chosen who goes first and with which roll:\n%v\nYou are playing Red.\n",
 		board)
@@ -113,11 +293,11 @@ func main() {
 	if *debug {
 		ai.StartDebugging()
 	}
-	redChooser := ai.MakePlayerConservative(0, nil)
+	redChooser := ai.MakePlayerConservative(uint64(*plies), nil)
 	chooser := func(s []*brd.Board) []brd.AnalyzedBoard {
 		if s[0].Roller == brd.White {
 			ai.StopDebugging()
-			c := ai.MakePlayerConservative(0, nil)(s)
+			c := ai.MakePlayerConservative(uint64(*plies), nil)(s)
 			if *debug {
 				ai.StartDebugging()
 			}
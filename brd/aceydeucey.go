@@ -0,0 +1,49 @@
+package brd
+
+// Acey-deucey uses the standard board, directions, hitting, and scoring, but
+// every checker starts off the board. A checker enters play just like a
+// checker on the bar (White onto [1, 6], Red onto [19, 24]) except that you
+// needn't enter all of them before moving the ones already in play. Checkers
+// on the bar, on the other hand, must enter before anything else moves. You
+// can't bear off while any of your checkers have yet to enter.
+//
+// Rolling <2 1> is special: after playing it you choose any doublet and play
+// that, and then you roll again. We represent that as a sequence of turns by
+// the same Roller. LegalContinuations() offers each way of playing <2 1> once
+// for each Bonus doublet, TakeTurn() hands the Bonus to the Roller as its Roll
+// and sets RollAgain, and the TakeTurn() after that rolls for the same Roller.
+// If you can't play all of <2 1> you don't get a Bonus.
+
+func newAceyDeucey() *Board {
+	board := Board{Stakes: 1, WhiteCanDouble: true, RedCanDouble: true, Variant: AceyDeucey}
+	board.ToEnter[White] = 15
+	board.ToEnter[Red] = 15
+	return &board
+}
+
+// Returns six Boards for each candidate, one for each Bonus, if b.Roll is <2
+// 1> and the candidates used it all. Returns the candidates otherwise.
+func (b *Board) withBonuses(candidates []*Board) []*Board {
+	if b.Roll != (Roll{2, 1}) || len(candidates[0].RollUsed.Dice()) != 2 {
+		return candidates
+	}
+	results := make([]*Board, 0, 6*len(candidates))
+	for _, c := range candidates {
+		for bonus := Die(1); bonus <= 6; bonus++ {
+			next := boardPool.Get().(*Board)
+			*next = *c
+			next.Bonus = bonus
+			results = append(results, next)
+		}
+		boardPool.Put(c)
+	}
+	return results
+}
+
+// The Roller plays its chosen doublet and then rolls again.
+func (b *Board) takeBonus() {
+	b.Roll = Roll{b.Bonus, b.Bonus, b.Bonus, b.Bonus}
+	b.RollUsed = Roll{}
+	b.Bonus = ZeroDie
+	b.RollAgain = true
+}
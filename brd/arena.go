@@ -0,0 +1,65 @@
+package brd
+
+// Scratch memory for Board.ForEachLegalContinuation(). The zero value is ready
+// to use. Reusing one Arena for many calls means that, once it has grown large
+// enough, move generation allocates nothing at all. An Arena is not safe for
+// concurrent use; give each goroutine its own.
+type Arena struct {
+	search nodeSearch
+	board  Board // what the visitor sees
+}
+
+// Calls visit with each Board that LegalContinuations() would return, in the
+// same order, until visit returns false.
+//
+// The Board passed to visit belongs to the Arena and is overwritten by the next
+// one, so copy it if you want to keep it. visit must not mutate it and must not
+// use the Arena.
+//
+// For the Standard Variant this makes zero heap allocations once the Arena is
+// warm. Other Variants fall back on LegalContinuations().
+func (b *Board) ForEachLegalContinuation(arena *Arena, visit func(*Board) bool) {
+	if b.Variant != Standard {
+		candidates := b.LegalContinuations()
+		for _, c := range candidates {
+			arena.board = *c
+			if !visit(&arena.board) {
+				break
+			}
+		}
+		OptionallyReturnBoardsToPool(candidates, b) // b itself is the no-op continuation
+		return
+	}
+	s := &arena.search
+	if s.visited == nil {
+		s.visited = make(map[uint64]nodeKey, 64)
+	}
+	s.start(b)
+	// The same filtering as LegalContinuations(): use as many dice as you
+	// can, and the larger die if you can use only one.
+	maxDiceUsed := 0
+	var maxDieUsed Die
+	for i := range s.leaves {
+		numUsed := s.leaves[i].RollUsed.numDice()
+		if numUsed > maxDiceUsed {
+			maxDiceUsed = numUsed
+			maxDieUsed = ZeroDie
+		}
+		if numUsed == maxDiceUsed {
+			maxDieUsed = maxDie(maxDieUsed, s.leaves[i].RollUsed[0])
+		}
+	}
+	for i := range s.leaves {
+		n := &s.leaves[i]
+		if n.RollUsed.numDice() != maxDiceUsed || (maxDiceUsed == 1 && n.RollUsed[0] != maxDieUsed) {
+			continue
+		}
+		arena.board = *b
+		arena.board.Pips = n.Position.Pips()
+		arena.board.Roll = n.Roll
+		arena.board.RollUsed = n.RollUsed
+		if !visit(&arena.board) {
+			return
+		}
+	}
+}
@@ -2,7 +2,6 @@ package brd
 
 import (
 	"fmt"
-	"math/rand"
 	"strings"
 	"sync"
 )
@@ -39,11 +38,17 @@ type Board struct {
 	Roll           Roll // unused
 	RollUsed       Roll // used
 	Roller         Checker
+	Variant        Variant  // zero value means standard backgammon. See NewVariant()
+	ToEnter        [3]uint8 // AceyDeucey: ToEnter[White] and ToEnter[Red] are the checkers that have yet to enter play
+	Bonus          Die      // AceyDeucey: the doublet the Roller chose after playing <2 1>. TakeTurn() hands it over.
+	RollAgain      bool     // AceyDeucey: the Roller takes another turn after this one
+	FirstTurns     uint8    // LongNardy: how many players have yet to finish their first turn, the Roller's being the current one if nonzero
 	Stakes         int
 	MatchScore     Score // zero value means we are not doing tournament play. NB: Use SetScore()
 	WhiteCanDouble bool
 	RedCanDouble   bool
 	Pips           Points28 // red borne off, the 24 points of the board, white borne off, red bar, white bar. Pips[1:25] are the 24 pips.
+	Rules          Rules    // zero value means standard rules. NB: Use SetRules()
 }
 
 // TODO(chandler37): Test the AIs with a 6-prime from [6, 12) or even farther from home.
@@ -135,6 +140,9 @@ func (b *Board) PlayGame(logState interface{}, chooser Chooser, logger func(inte
 
 // Flips the Roller, offers a double, rolls new dice, alters the MatchScore.
 //
+// In AceyDeucey the Roller keeps going after playing <2 1>: this hands over
+// the Bonus doublet, and next time it rolls again without offering a double.
+//
 // offerDouble and acceptDouble may be nil.
 //
 // Invariant: the receiver was returned by LegalContinuations()
@@ -146,9 +154,21 @@ func (b *Board) TakeTurn(offerDouble, acceptDouble func(*Board) bool) (victor Ch
 		score = b.MatchScore
 		return
 	}
+	if b.Bonus != ZeroDie {
+		b.takeBonus()
+		return
+	}
+	if b.RollAgain {
+		b.RollAgain = false
+		b.Roll.New(&b.RollUsed)
+		return
+	}
+	if b.FirstTurns > 0 {
+		b.FirstTurns--
+	}
 	b.Roller = b.Roller.OtherColor()
 	b.Roll = Roll{}
-	if (b.Roller == Red && b.RedCanDouble) || (b.Roller == White && b.WhiteCanDouble) {
+	if ((b.Roller == Red && b.RedCanDouble) || (b.Roller == White && b.WhiteCanDouble)) && b.cubeBelowMax() {
 		if offerDouble != nil && offerDouble(b) {
 			if acceptDouble(b) {
 				b.Stakes *= 2
@@ -170,6 +190,42 @@ func (b *Board) TakeTurn(offerDouble, acceptDouble func(*Board) bool) (victor Ch
 	return
 }
 
+// Returns the legal continuations for whoever rolls next had they rolled
+// roll, i.e., what TakeTurn() followed by LegalContinuations() would give if
+// the dice came up roll and nobody doubled. Neither mutates b nor touches the
+// RNG. roll must be a valid Roll such as those from AllRolls().
+//
+// Invariant: the receiver was returned by LegalContinuations() and the game is
+// not over.
+//
+// See also OptionallyReturnBoardsToPool().
+func (b *Board) ContinuationsForRoll(roll Roll) []*Board {
+	if iv := roll.invalidity(); iv != "" {
+		panic(iv)
+	}
+	if b.Bonus != ZeroDie {
+		panic("the Bonus is the next roll")
+	}
+	next := boardPool.Get().(*Board)
+	*next = *b
+	if next.RollAgain {
+		next.RollAgain = false
+	} else {
+		next.Roller = next.Roller.OtherColor()
+	}
+	if next.FirstTurns > 0 {
+		next.FirstTurns--
+	}
+	next.Roll = roll
+	next.RollUsed = Roll{}
+	continuations := next.LegalContinuations()
+	// When the Roller can't move, next itself may be the only continuation.
+	if continuations[0] != next {
+		boardPool.Put(next)
+	}
+	return continuations
+}
+
 func (b *Board) NumPointsBlocked(player Checker) (result int) {
 	for i := 1; i < 25; i++ {
 		if b.Pips[i].MadeBy(player) {
@@ -195,6 +251,9 @@ func (b *Board) LengthOfMaxPrime(player Checker) (result int) {
 }
 
 func (b *Board) NumCheckersHome(player Checker) (result int) {
+	if b.Variant == LongNardy {
+		return b.nardyNumCheckersHome(player)
+	}
 	home := b.Pips[19:25]
 	if player == Red {
 		home = b.Pips[1:7]
@@ -214,6 +273,10 @@ func (b *Board) NumCheckersHome(player Checker) (result int) {
 }
 
 func (b *Board) PipCount(player Checker) (result int) {
+	if b.Variant == LongNardy {
+		return b.nardyPipCount(player)
+	}
+	result += 25 * int(b.ToEnter[player])
 	if player == White {
 		result += 25 * b.Pips[BarWhitePip].NumCheckers()
 		for i := 1; i < 25; i++ {
@@ -229,9 +292,12 @@ func (b *Board) PipCount(player Checker) (result int) {
 }
 
 func (b *Board) PipCountOfFarthestChecker(player Checker) int {
+	if b.Variant == LongNardy {
+		return b.nardyPipCountOfFarthestChecker(player)
+	}
 	if player == White {
 		extremeWhite := -1
-		if b.Pips[BarWhitePip].NumCheckers() > 0 {
+		if b.Pips[BarWhitePip].NumCheckers() > 0 || b.ToEnter[White] > 0 {
 			extremeWhite = 0
 		} else {
 			for i := 1; i < 25; i++ {
@@ -244,7 +310,7 @@ func (b *Board) PipCountOfFarthestChecker(player Checker) int {
 		return 25 - extremeWhite
 	}
 	extremeRed := -1
-	if b.Pips[BarRedPip].NumCheckers() > 0 {
+	if b.Pips[BarRedPip].NumCheckers() > 0 || b.ToEnter[Red] > 0 {
 		extremeRed = 25
 	} else {
 		for i := 24; i > 0; i-- {
@@ -258,9 +324,14 @@ func (b *Board) PipCountOfFarthestChecker(player Checker) int {
 }
 
 // A "race" is when it is impossible for either player to hit the other.
+//
+// Nobody ever hits in Long Nardy.
 func (b *Board) Racing() bool {
+	if b.Variant == LongNardy {
+		return true
+	}
 	extremeWhite := -1
-	if b.Pips[BarWhitePip].NumCheckers() > 0 {
+	if b.Pips[BarWhitePip].NumCheckers() > 0 || b.ToEnter[White] > 0 {
 		extremeWhite = 0
 	} else {
 		for i := 1; i < 25; i++ {
@@ -272,7 +343,7 @@ func (b *Board) Racing() bool {
 	}
 
 	extremeRed := -1
-	if b.Pips[BarRedPip].NumCheckers() > 0 {
+	if b.Pips[BarRedPip].NumCheckers() > 0 || b.ToEnter[Red] > 0 {
 		extremeRed = 25
 	} else {
 		for i := 24; i > 0; i-- {
@@ -288,7 +359,7 @@ func (b *Board) Racing() bool {
 
 func (b *Board) isHittable(i int, player Checker) bool {
 	if player == Red {
-		if b.Pips[BarWhitePip].NumCheckers() > 0 {
+		if b.Pips[BarWhitePip].NumCheckers() > 0 || b.ToEnter[White] > 0 {
 			return true
 		} else {
 			for j := i - 1; j > 0; j-- {
@@ -302,7 +373,7 @@ func (b *Board) isHittable(i int, player Checker) bool {
 	if player != White {
 		panic(player.String())
 	}
-	if b.Pips[BarRedPip].NumCheckers() > 0 {
+	if b.Pips[BarRedPip].NumCheckers() > 0 || b.ToEnter[Red] > 0 {
 		return true
 	} else {
 		for j := i + 1; j < 25; j++ {
@@ -346,6 +417,9 @@ func (b *Board) BlotLiability(player Checker, includeUnhittable bool) (result in
 // The resulting Boards have the same Roller and the same dice (though they may
 // be shifted from Roll to RollUsed). You must call TakeTurn() next.
 //
+// In AceyDeucey, having played <2 1> you choose a doublet, so each way of
+// playing <2 1> appears six times, once for each possible Bonus.
+//
 // See also OptionallyReturnBoardsToPool().
 func (b *Board) LegalContinuations() []*Board {
 	candidates := b.quasiLegalContinuations()
@@ -368,6 +442,9 @@ func (b *Board) LegalContinuations() []*Board {
 	// except those that use both.)
 	arbitraryCandidate := maxCandidates[0]
 	if len(arbitraryCandidate.RollUsed.Dice()) != 1 {
+		if b.Variant == AceyDeucey {
+			return b.withBonuses(maxCandidates)
+		}
 		return maxCandidates
 	}
 	results := make([]*Board, 0, len(maxCandidates))
@@ -397,6 +474,12 @@ func (b *Board) Equals(o Board) bool {
 	if b.Roller != o.Roller {
 		return false
 	}
+	if b.Variant != o.Variant || b.Rules != o.Rules {
+		return false
+	}
+	if b.ToEnter != o.ToEnter || b.Bonus != o.Bonus || b.RollAgain != o.RollAgain || b.FirstTurns != o.FirstTurns {
+		return false
+	}
 	if !b.Roll.Equals(o.Roll) {
 		return false
 	}
@@ -416,6 +499,10 @@ func (b *Board) Equals(o Board) bool {
 }
 
 func New(paranoid bool) *Board {
+	return NewGame(Standard, Rules{}, paranoid)
+}
+
+func newStandard() *Board {
 	board := Board{Stakes: 1, WhiteCanDouble: true, RedCanDouble: true}
 	board.Pips[1].Reset(2, White)
 	board.Pips[24].Reset(2, Red)
@@ -425,19 +512,6 @@ func New(paranoid bool) *Board {
 	board.Pips[17].Reset(3, White)
 	board.Pips[12].Reset(5, White)
 	board.Pips[13].Reset(5, Red)
-
-	board.Roller = players[rand.Intn(2)]
-	for {
-		board.Roll.New(&board.RollUsed)
-		if board.Roll[0] != board.Roll[1] {
-			break
-		}
-	}
-	if paranoid {
-		if v := board.Invalidity(EnforceRollValidity); v != "" {
-			panic(v)
-		}
-	}
 	return &board
 }
 
@@ -457,6 +531,12 @@ func (b Board) String() string {
 	if !b.MatchScore.Equals(Score{}) {
 		score = ", " + b.MatchScore.String()
 	}
+	if b.Variant != Standard {
+		score += ", " + b.Variant.String()
+	}
+	if b.Rules != (Rules{}) {
+		score += ", " + b.Rules.String()
+	}
 	barWhite := ""
 	l := []string{}
 	for n := 0; n < b.Pips[BarWhitePip].NumCheckers(); n++ {
@@ -473,6 +553,12 @@ func (b Board) String() string {
 	if len(l) > 0 {
 		barRed = fmt.Sprintf(", %v on bar", strings.Join(l, ""))
 	}
+	toEnter := ""
+	for _, player := range players {
+		if n := int(b.ToEnter[player]); n > 0 {
+			toEnter += fmt.Sprintf(", %s to enter", strings.Repeat(player.String(), n))
+		}
+	}
 	borneOffWhite := ""
 	num := b.Pips[BorneOffWhitePip].NumCheckers()
 	if num > 0 {
@@ -509,14 +595,20 @@ func (b Board) String() string {
 	if len(b.RollUsed.Dice()) > 0 {
 		usedRoll = fmt.Sprintf(" after playing %v", b.RollUsed)
 	}
+	if b.Bonus != ZeroDie {
+		usedRoll += fmt.Sprintf(" choosing %v", Roll{b.Bonus, b.Bonus, b.Bonus, b.Bonus})
+	}
+	if b.RollAgain {
+		usedRoll += " then rolling again"
+	}
 	toPlay := ""
 	if len(b.Roll.Dice()) > 0 {
 		toPlay = fmt.Sprintf(" to play %v", b.Roll)
 	}
 	return fmt.Sprintf(
-		"{%v%s%s; %s; %v%s%s%s%s%s}",
+		"{%v%s%s; %s; %v%s%s%s%s%s%s}",
 		b.Roller, toPlay, usedRoll, stakes, strings.Join(prettyPips, " "), barWhite,
-		barRed, borneOffWhite, borneOffRed, score)
+		barRed, toEnter, borneOffWhite, borneOffRed, score)
 }
 
 const (
@@ -546,6 +638,26 @@ func (b Board) Invalidity(ignoreRoll bool) string {
 	if b.Pips[BorneOffRedPip].NumWhite() > 0 {
 		return "Red on BorneOffRedPip"
 	}
+	if b.Variant == LongNardy && (numWhite > 0 || numRed > 0) {
+		return "there is no bar in LongNardy"
+	}
+	if b.Variant != AceyDeucey && (b.ToEnter != [3]uint8{} || b.Bonus != ZeroDie || b.RollAgain) {
+		return fmt.Sprintf("only AceyDeucey uses ToEnter, Bonus, and RollAgain, not %v", b.Variant)
+	}
+	if b.Variant != LongNardy && b.FirstTurns != 0 {
+		return fmt.Sprintf("only LongNardy uses FirstTurns, not %v", b.Variant)
+	}
+	if b.FirstTurns > 2 {
+		return "FirstTurns out of range"
+	}
+	if b.Bonus > 6 {
+		return "Bonus out of range"
+	}
+	if iv := b.Rules.invalidity(b.Stakes); iv != "" {
+		return iv
+	}
+	numWhite += int(b.ToEnter[White])
+	numRed += int(b.ToEnter[Red])
 	numWhite += b.Pips[BorneOffWhitePip].NumWhite()
 	numRed += b.Pips[BorneOffRedPip].NumRed()
 	for pointNumber := 1; pointNumber < 25; pointNumber++ {
@@ -585,6 +697,9 @@ func max(i, j int) int {
 
 // assumes victory for b.Roller.
 func (b *Board) victorMultiplier() int {
+	if b.Variant == LongNardy {
+		return b.nardyVictorMultiplier()
+	}
 	opponentBar := BarWhitePip
 	opponentBorne := BorneOffWhitePip
 	homeStart, homeEnd := 1, 6
@@ -593,7 +708,7 @@ func (b *Board) victorMultiplier() int {
 		opponentBorne = BorneOffRedPip
 		homeStart, homeEnd = 19, 24
 	}
-	if b.Pips[opponentBar].NumCheckers() > 0 {
+	if b.Pips[opponentBar].NumCheckers() > 0 || b.ToEnter[b.Roller.OtherColor()] > 0 {
 		return 3
 	}
 	for x := homeStart; x <= homeEnd; x++ {
@@ -607,6 +722,12 @@ func (b *Board) victorMultiplier() int {
 	return 1
 }
 
+// If the Roller has borne off all its checkers, returns it and the stakes it
+// won, just as TakeTurn() would. Otherwise returns NoChecker. Read-only.
+func (b *Board) Victor() (victor Checker, stakes int) {
+	return b.victor()
+}
+
 func (b *Board) victor() (victor Checker, stakes int) {
 	borne := BorneOffRedPip
 	if b.Roller == White {
@@ -614,7 +735,13 @@ func (b *Board) victor() (victor Checker, stakes int) {
 	}
 	if b.Pips[borne].NumCheckers() == 15 {
 		victor = b.Roller
-		stakes = b.victorMultiplier() * b.Stakes
+		multiplier := b.victorMultiplier()
+		if b.Rules.NoGammons {
+			multiplier = 1
+		} else if b.Rules.NoBackgammons && multiplier > 2 {
+			multiplier = 2
+		}
+		stakes = multiplier * b.Stakes
 		return
 	}
 	return
@@ -639,26 +766,42 @@ func (b *Board) pipIsBlockedByOpponent(i int) bool {
 }
 
 // Returns a Board or nil depending on whether or not that point was open.
-func (b *Board) comeOffTheBar(die Die) *Board {
+// Given b's Hash(), also returns the Board's.
+func (b *Board) comeOffTheBar(die Die, hash uint64) (*Board, uint64) {
+	result, hash := b.enter(die, hash)
+	if result != nil {
+		barPip := BarWhitePip
+		if b.Roller == Red {
+			barPip = BarRedPip
+		}
+		hash ^= result.pipHash(barPip)
+		result.Pips[barPip].Subtract()
+		hash ^= result.pipHash(barPip)
+	}
+	return result, hash
+}
+
+// Like comeOffTheBar() except that the caller must remove the entering
+// Checker from wherever it was, e.g. the bar.
+func (b *Board) enter(die Die, hash uint64) (*Board, uint64) {
 	// At the start, Pips[1] is Point{White, White}. If b.Roller is White, then
 	// we come in on the die Point. Else the 25-die point.
 	i := int(die)
-	barPip := BarWhitePip
 	otherPlayersBar := BarRedPip
 	switch b.Roller {
 	case Red:
 		i = 25 - int(die)
-		barPip = BarRedPip
 		otherPlayersBar = BarWhitePip
 	case White:
 	default:
 		panic("bad b.Roller")
 	}
 	if b.pipIsBlockedByOpponent(i) {
-		return nil
+		return nil, 0
 	}
 	result := boardPool.Get().(*Board)
 	*result = *b
+	hash ^= rollHashDelta(result.Roll, die) ^ result.pipHash(i) ^ result.pipHash(otherPlayersBar)
 	result.Roll = result.Roll.Use(die, &result.RollUsed)
 	if other := b.Roller.OtherColor(); result.Pips[i].Num(other) > 0 {
 		result.Pips[i].Reset(0, White)
@@ -668,25 +811,26 @@ func (b *Board) comeOffTheBar(die Die) *Board {
 		}
 	}
 	result.Pips[i].Add(b.Roller)
-	result.Pips[barPip].Subtract()
-	return result
+	hash ^= result.pipHash(i) ^ result.pipHash(otherPlayersBar)
+	return result, hash
 }
 
 // len(possibilities) will be zero if there's nothing on the bar or if there's
 // something on the bar that is blocked from coming in. It will be multiple
-// Boards if a Checker on the bar can come in on multiple Points.
-func (b *Board) continuationsOffTheBar() (possibilities []*Board) {
+// Boards if a Checker on the bar can come in on multiple Points. hash is
+// b.Hash().
+func (b *Board) continuationsOffTheBar(hash uint64) (possibilities []boardNode) {
 	// This is recursive, and the base case for our recursion is if (1)
 	// b.Roller has none on the bar or (2) the b.Roll is exhausted.
 	if numOnBar := b.numCheckersRollerHasOnTheBar(); numOnBar > 0 {
 		for _, die := range b.Roll.UniqueDice() {
-			if next := b.comeOffTheBar(die); next != nil {
-				cont := next.continuationsOffTheBar()
+			if next, nextHash := b.comeOffTheBar(die, hash); next != nil {
+				cont := next.continuationsOffTheBar(nextHash)
 				for _, c := range cont {
 					possibilities = append(possibilities, c)
 				}
 				if len(cont) == 0 {
-					possibilities = append(possibilities, next)
+					possibilities = append(possibilities, boardNode{next, nextHash})
 				} else {
 					boardPool.Put(next)
 				}
@@ -705,7 +849,7 @@ func (b *Board) numCheckersRollerHasOnTheBar() int {
 }
 
 func (b *Board) canBearOff() bool {
-	if b.numCheckersRollerHasOnTheBar() > 0 {
+	if b.numCheckersRollerHasOnTheBar() > 0 || b.ToEnter[b.Roller] > 0 {
 		return false
 	}
 	if b.Roller == White {
@@ -775,8 +919,9 @@ func (b *Board) canMoveChecker(startPipIndex int, die Die) (targetPip int, can b
 
 // Invariant: len(b.Roll.Dice()) > 0 && b.numCheckersRollerHasOnTheBar() == 0
 //
-// Returns len(continuations)==0 when the only answer is b itself.
-func (b *Board) quasiLegalPostBarContinuations() (continuations []*Board) {
+// Returns len(continuations)==0 when the only answer is b itself. hash is
+// b.Hash().
+func (b *Board) quasiLegalPostBarContinuations(hash uint64) (continuations []boardNode) {
 	remainingDice := b.Roll.Dice()
 	if len(remainingDice) == 0 || b.numCheckersRollerHasOnTheBar() > 0 {
 		return
@@ -785,25 +930,42 @@ func (b *Board) quasiLegalPostBarContinuations() (continuations []*Board) {
 	// <5 6> and <6 5> to see the possibility of moving from point 1 to point
 	// 12. This does so.
 	for _, die := range remainingDice {
+		if b.ToEnter[b.Roller] > 0 {
+			if next, nextHash := b.enter(die, hash); next != nil {
+				n := next.ToEnter[b.Roller]
+				nextHash ^= zobrist.toEnter[b.Roller][n] ^ zobrist.toEnter[b.Roller][n-1]
+				next.ToEnter[b.Roller]--
+				cont := next.quasiLegalPostBarContinuations(nextHash)
+				if len(cont) == 0 {
+					continuations = append(continuations, boardNode{next, nextHash})
+				} else {
+					boardPool.Put(next)
+					continuations = append(continuations, cont...)
+				}
+			}
+		}
 		for i := 1; i < 25; i++ {
 			if b.Pips[i].Num(b.Roller) > 0 {
 				if targetPip, can := b.canMoveChecker(i, die); can {
 					next := boardPool.Get().(*Board)
 					*next = *b
+					other := b.Roller.OtherColor()
+					bar := BarRedPip
+					if other == White {
+						bar = BarWhitePip
+					}
+					nextHash := hash ^ rollHashDelta(next.Roll, die) ^ next.pipHash(i) ^ next.pipHash(targetPip) ^ next.pipHash(bar)
 					next.Pips[i].Subtract()
-					if other := b.Roller.OtherColor(); next.Pips[targetPip].Num(other) > 0 {
+					if next.Pips[targetPip].Num(other) > 0 {
 						next.Pips[targetPip].Subtract()
-						bar := BarRedPip
-						if other == White {
-							bar = BarWhitePip
-						}
 						next.Pips[bar].Add(other)
 					}
 					next.Pips[targetPip].Add(b.Roller)
+					nextHash ^= next.pipHash(i) ^ next.pipHash(targetPip) ^ next.pipHash(bar)
 					next.Roll = next.Roll.Use(die, &next.RollUsed)
-					cont := next.quasiLegalPostBarContinuations()
+					cont := next.quasiLegalPostBarContinuations(nextHash)
 					if len(cont) == 0 {
-						continuations = append(continuations, next)
+						continuations = append(continuations, boardNode{next, nextHash})
 					} else {
 						boardPool.Put(next)
 						continuations = append(continuations, cont...)
@@ -819,20 +981,19 @@ func (b *Board) quasiLegalPostBarContinuations() (continuations []*Board) {
 }
 
 // to ease testing, this must be stable, i.e., not rearranging things
-func uniqueContinuations(continuations []*Board) []*Board {
-	result := make([]*Board, 0, len(continuations))
+func uniqueContinuations(continuations []boardNode) []boardNode {
+	result := make([]boardNode, 0, len(continuations))
+	seen := make(map[uint64]int, len(continuations)) // hash => index into result
 	for _, c := range continuations {
-		unique := true
-		for _, r := range result {
-			if c.Equals(*r) {
-				unique = false
-				boardPool.Put(c)
-				break
-			}
+		h := c.Hash
+		if i, ok := seen[h]; ok && (c.Board.Equals(*result[i].Board) || c.Board.equalsAnyNode(result)) {
+			boardPool.Put(c.Board)
+			continue
 		}
-		if unique {
-			result = append(result, c)
+		if _, ok := seen[h]; !ok {
+			seen[h] = len(result)
 		}
+		result = append(result, c)
 	}
 	if len(result) == 0 {
 		panic(fmt.Sprintf("input had %d elements", len(continuations)))
@@ -840,6 +1001,25 @@ func uniqueContinuations(continuations []*Board) []*Board {
 	return result
 }
 
+func (b *Board) equalsAny(boards []*Board) bool {
+	for _, o := range boards {
+		if b.Equals(*o) {
+			return true
+		}
+	}
+	return false
+}
+
+// Distinguishes a duplicate from a hash collision.
+func (b *Board) equalsAnyNode(nodes []boardNode) bool {
+	for _, o := range nodes {
+		if b.Equals(*o.Board) {
+			return true
+		}
+	}
+	return false
+}
+
 // For a <6 3> this returns Boards where we took just the <6>, just the <3>,
 // and also, if possible, where we took both. The legal continuations are the ones
 // where we took both, or, if there are no such continuations, the boards where we took
@@ -849,19 +1029,37 @@ func uniqueContinuations(continuations []*Board) []*Board {
 // but not four, you must. If you can take two, you must. if you can take one,
 // you must.)
 func (b *Board) quasiLegalContinuations() []*Board {
-	barContinuations := b.continuationsOffTheBar()
+	switch b.Variant {
+	case Standard:
+		return b.positionQuasiLegalContinuations()
+	case LongNardy:
+		return b.nardyQuasiLegalContinuations()
+	}
+	return b.boardQuasiLegalContinuations()
+}
+
+// Like quasiLegalContinuations() but copying Boards at every step. Only
+// AceyDeucey needs this because Positions cannot represent ToEnter.
+func (b *Board) boardQuasiLegalContinuations() []*Board {
+	return boardsOf(b.boardContinuations())
+}
+
+// Like boardQuasiLegalContinuations() but with the Hash() of each.
+func (b *Board) boardContinuations() []boardNode {
+	hash := b.Hash()
+	barContinuations := b.continuationsOffTheBar(hash)
 	if len(barContinuations) == 0 {
-		barContinuations = []*Board{b}
+		barContinuations = []boardNode{{b, hash}}
 	}
 	// the max capacity we see when PlayerConservative plays itself is
 	// 206,159,153,140,135,129,107,96,92,89,88,88,85,79,76,76,75,74,73,73,73,71,55,53,47,41,39,27,26,25
 	// for a few random trials. Benchmarking doesn't show an improvement when
 	// we give a high capacity, though:
-	continuations := []*Board{}
+	continuations := []boardNode{}
 	for _, next := range barContinuations {
-		cont := next.quasiLegalPostBarContinuations()
+		cont := next.Board.quasiLegalPostBarContinuations(next.Hash)
 		if len(cont) == 0 {
-			cont = []*Board{next}
+			cont = []boardNode{next}
 		}
 		continuations = append(continuations, cont...)
 	}
@@ -885,9 +1083,10 @@ func (c Checker) String() string {
 	return "0"
 }
 
+// Set the Rules first: the Crawford rule applies unless they say otherwise.
 func (b *Board) SetScore(score Score) {
 	b.MatchScore = score
-	if score.CrawfordRuleAppliesNextGame() {
+	if !b.Rules.NoCrawfordRule && score.CrawfordRuleAppliesNextGame() {
 		b.WhiteCanDouble = false
 		b.RedCanDouble = false
 		b.MatchScore.AlreadyPlayedCrawfordGame = true
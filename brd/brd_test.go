@@ -72,11 +72,11 @@ func BenchmarkComingOffTheBar(b *testing.B) {
 		board.Pips[BarWhitePip].Reset(5, White)
 		board.Pips[6].Reset(1, Red)
 		board.Pips[BarRedPip].Reset(4, Red)
-		nextBoards := board.continuationsOffTheBar()
+		nextBoards := board.continuationsOffTheBar(board.Hash())
 		if len(nextBoards) != 1 {
 			panic(fmt.Sprintf("nextBoards is %v", nextBoards))
 		}
-		if x := nextBoards[0].Pips[6].String(); x != "WWWW" {
+		if x := nextBoards[0].Board.Pips[6].String(); x != "WWWW" {
 			panic(fmt.Sprintf("x is %v", x))
 		}
 	}
@@ -162,17 +162,19 @@ func TestNewAndStringerAndInvalidity(t *testing.T) {
 		// 2. They have heuristics to avoid backgammons and gammons.
 		b.MatchScore = Score{Goal: 1}
 		assertValidity(b, t)
-		if s := b.String(); s != "{W to play   62; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrr 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr, Score{Goal:1,W:0,r:0,Crawford on,inactive}}" {
+		if s := b.String(); s != "{W to play   62; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrr 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr, Score{Goal:1,W:0,r:0,Crawford inactive}}" {
 			t.Errorf("got %v", s)
 		}
-		b.MatchScore = Score{Goal: 1, WhiteScore: 2, NoCrawfordRule: true}
+		b.MatchScore = Score{Goal: 1, WhiteScore: 2}
+		b.Rules = Rules{NoCrawfordRule: true}
 		assertValidity(b, t)
-		if s := b.String(); s != "{W to play   62; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrr 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr, Score{Goal:1,W:2,r:0,Crawford off}}" {
+		if s := b.String(); s != "{W to play   62; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrr 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr, Score{Goal:1,W:2,r:0,Crawford inactive}, Rules{Crawford off,RerollDoublets,AutoDbl:0,MaxCube:0,Gammons on}}" {
 			t.Errorf("got %v", s)
 		}
+		b.Rules = Rules{}
 		b.MatchScore = Score{Goal: 1, WhiteScore: 2, AlreadyPlayedCrawfordGame: true}
 		assertValidity(b, t)
-		if s := b.String(); s != "{W to play   62; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrr 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr, Score{Goal:1,W:2,r:0,Crawford on,dormant}}" {
+		if s := b.String(); s != "{W to play   62; !dbl; 1:WW 2: 3: 4: 5: 6:rrrrr 7: 8:rrr 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr, Score{Goal:1,W:2,r:0,Crawford dormant}}" {
 			t.Errorf("got %v", s)
 		}
 	}
@@ -848,7 +850,7 @@ func TestComingOffTheBar(t *testing.T) {
 				"bad initializer %d: expected %v but got %v",
 				exNum, ex.InitializerCheck, y)
 		}
-		candidates := board.continuationsOffTheBar()
+		candidates := boardsOf(board.continuationsOffTheBar(board.Hash()))
 		if expected := len(ex.continuations); len(candidates) != expected {
 			t.Errorf(
 				"exNum=%d expected %d candidates, not %v\nexpected: %v",
@@ -940,7 +942,7 @@ func TestRollEquals(t *testing.T) {
 }
 
 func TestBoardMemoryFootprint(t *testing.T) {
-	if s := unsafe.Sizeof(*New(true)); s != 88 {
+	if s := unsafe.Sizeof(*New(true)); s != 96 {
 		pair := runtime.GOOS + "-" + runtime.GOARCH
 		t.Fatalf(
 			"sizeof(Board) on %s is %d. This is not necessarily a problem, but you run the benchmarks again with `make bench`",
@@ -966,7 +968,7 @@ func TestTakeTurnSingleStakes(t *testing.T) {
 		t.Fatalf("TestLegalContinuations has this guy... %v", s)
 	}
 	victor, stakes, score := next[0].TakeTurn(nil, nil)
-	if victor != White || stakes != 1 || score.String() != "Score{Goal:0,W:1,r:0,Crawford on,inactive}" {
+	if victor != White || stakes != 1 || score.String() != "Score{Goal:0,W:1,r:0,Crawford inactive}" {
 		t.Errorf("victor=%v stakes=%v score=%v", victor, stakes, score)
 	}
 }
@@ -1165,7 +1167,7 @@ func TestPlayGame(t *testing.T) {
 			White,
 			3,
 			64,
-			"{W after playing   51; !dbl; 1:rrrrrrrrrrrr 2:r 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13:r 14: 15: 16: 17: 18: 19: 20:r 21: 22: 23: 24:, 15 W off, Score{Goal:0,W:3,r:0,Crawford on,inactive}}",
+			"{W after playing   51; !dbl; 1:rrrrrrrrrrrr 2:r 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13:r 14: 15: 16: 17: 18: 19: 20:r 21: 22: 23: 24:, 15 W off, Score{Goal:0,W:3,r:0,Crawford inactive}}",
 			func(s []*Board) []AnalyzedBoard {
 				return []AnalyzedBoard{AnalyzedBoard{Board: s[0]}}
 			},
@@ -1184,7 +1186,7 @@ func TestPlayGame(t *testing.T) {
 			White,
 			2,
 			176,
-			"{W to play  666 after playing    6; !dbl; 1:rrrrrrrrrrr 2:r 3:r 4: 5: 6:r 7:r 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, 15 W off, Score{Goal:0,W:2,r:0,Crawford on,inactive}}", // TODO(chandler37): Really? Study the entire game.
+			"{W to play  666 after playing    6; !dbl; 1:rrrrrrrrrrr 2:r 3:r 4: 5: 6:r 7:r 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, 15 W off, Score{Goal:0,W:2,r:0,Crawford inactive}}", // TODO(chandler37): Really? Study the entire game.
 			func(s []*Board) []AnalyzedBoard {
 				return []AnalyzedBoard{AnalyzedBoard{Board: s[rand.Intn(len(s))]}}
 			},
@@ -1203,7 +1205,7 @@ func TestPlayGame(t *testing.T) {
 			Red,
 			1,
 			56,
-			"{r after playing 4444; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22:WWWW 23:WWWWWWW 24:WW, 1 W off, 15 r off, Score{Goal:0,W:0,r:1,Crawford on,inactive}}",
+			"{r after playing 4444; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22:WWWW 23:WWWWWWW 24:WW, 1 W off, 15 r off, Score{Goal:0,W:0,r:1,Crawford inactive}}",
 			func(s []*Board) []AnalyzedBoard {
 				return []AnalyzedBoard{AnalyzedBoard{Board: s[rand.Intn(len(s))]}}
 			},
@@ -1222,7 +1224,7 @@ func TestPlayGame(t *testing.T) {
 			White,
 			3,
 			111,
-			"{W after playing 1111; !dbl; 1:rrrr 2:rrr 3:rr 4: 5: 6: 7: 8: 9: 10: 11: 12: 13:r 14: 15:r 16:r 17:r 18: 19:r 20: 21:r 22: 23: 24:, 15 W off, Score{Goal:0,W:3,r:0,Crawford on,inactive}}", // TODO(chandler37): Really? Study the entire game.
+			"{W after playing 1111; !dbl; 1:rrrr 2:rrr 3:rr 4: 5: 6: 7: 8: 9: 10: 11: 12: 13:r 14: 15:r 16:r 17:r 18: 19:r 20: 21:r 22: 23: 24:, 15 W off, Score{Goal:0,W:3,r:0,Crawford inactive}}", // TODO(chandler37): Really? Study the entire game.
 			func(s []*Board) []AnalyzedBoard {
 				return []AnalyzedBoard{AnalyzedBoard{Board: s[rand.Intn(len(s))]}}
 			},
@@ -1497,3 +1499,1061 @@ func TestPipCountOfFarthestChecker(t *testing.T) {
 }
 
 // TODO(chandler37): Consider https://github.com/andreyvit/diff for better tests.
+
+func TestLongNardy(t *testing.T) {
+	rand.Seed(1)
+	b := NewVariant(LongNardy, true)
+	if bs := b.String(); bs != "{r to play   43; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:rrrrrrrrrrrrrrr, LongNardy}" {
+		t.Fatalf("bs=%v", bs)
+	}
+	if b.PipCount(White) != 360 || b.PipCount(Red) != 360 || !b.Racing() {
+		t.Errorf("b=%v", b)
+	}
+	if b.PipCountOfFarthestChecker(White) != 24 || b.NumCheckersHome(White) != 0 {
+		t.Errorf("b=%v", b)
+	}
+	if b.Equals(*New(false)) {
+		t.Errorf("the Variant matters")
+	}
+	if v, err := ParseVariant("LongNardy"); v != LongNardy || err != nil {
+		t.Errorf("v=%v err=%v", v, err)
+	}
+
+	type example struct {
+		Initializer   func(*Board)
+		continuations []string
+	}
+	examples := [...]example{
+		// Only one checker may leave the head.
+		example{
+			func(b *Board) {
+				b.Roller = Red
+				b.Roll = Roll{4, 3}
+			},
+			[]string{
+				"{r after playing   43; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17:r 18: 19: 20: 21: 22: 23: 24:rrrrrrrrrrrrrr, LongNardy}",
+			}},
+		// ...except for <6 6> on the first turn. The opponent's head blocks
+		// the rest of the roll.
+		example{
+			func(b *Board) {
+				b.Roller = Red
+				b.Roll = Roll{6, 6, 6, 6}
+			},
+			[]string{
+				"{r to play   66 after playing   66; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18:rr 19: 20: 21: 22: 23: 24:rrrrrrrrrrrrr, LongNardy}",
+			}},
+		// ...which is no longer the first turn once each player has had one,
+		// even if the Roller couldn't move then.
+		example{
+			func(b *Board) {
+				b.Roller = Red
+				b.Roll = Roll{6, 6, 6, 6}
+				b.FirstTurns = 0
+			},
+			[]string{
+				"{r to play  666 after playing    6; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18:r 19: 20: 21: 22: 23: 24:rrrrrrrrrrrrrr, LongNardy}",
+			}},
+		// White runs from 12 down to 1 and then from 24 down to 13. A lone
+		// Red checker on 22 holds the point.
+		example{
+			func(b *Board) {
+				b.Roller = White
+				b.Roll = Roll{5, 4}
+				b.Pips[12].Reset(14, White)
+				b.Pips[3].Reset(1, White)
+				b.Pips[24].Reset(14, Red)
+				b.Pips[22].Reset(1, Red)
+			},
+			[]string{
+				"{W after playing   54; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2: 3:WW 4: 5: 6: 7: 8: 9: 10: 11: 12:WWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22:r 23: 24:rrrrrrrrrrrrrr, LongNardy}",
+				"{W after playing   54; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2: 3: 4: 5: 6: 7:W 8: 9: 10: 11: 12:WWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22:r 23:W 24:rrrrrrrrrrrrrr, LongNardy}",
+				"{W after playing   45; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12:WWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18:W 19: 20: 21: 22:r 23: 24:rrrrrrrrrrrrrr, LongNardy}",
+			}},
+		// Red may not complete the six-prime [2, 7] by playing 8=>7 because
+		// all of White is behind it.
+		example{
+			func(b *Board) {
+				b.Roller = Red
+				b.Roll = Roll{1}
+				b.Pips = Points28{}
+				b.Pips[12].Reset(15, White)
+				for i := 2; i < 7; i++ {
+					b.Pips[i].Reset(2, Red)
+				}
+				b.Pips[8].Reset(1, Red)
+				b.Pips[24].Reset(4, Red)
+			},
+			[]string{
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rr 4:rr 5:rr 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23:r 24:rrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rr 4:rr 5:rrr 6:r 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:rrrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rr 4:rrr 5:r 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:rrrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rrr 4:r 5:rr 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:rrrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rrr 3:r 4:rr 5:rr 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:rrrr, LongNardy}",
+			}},
+		// With one White checker past it, the same six-prime is fine.
+		example{
+			func(b *Board) {
+				b.Roller = Red
+				b.Roll = Roll{1}
+				b.Pips = Points28{}
+				b.Pips[12].Reset(14, White)
+				b.Pips[20].Reset(1, White)
+				for i := 2; i < 7; i++ {
+					b.Pips[i].Reset(2, Red)
+				}
+				b.Pips[8].Reset(1, Red)
+				b.Pips[24].Reset(4, Red)
+			},
+			[]string{
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rr 4:rr 5:rr 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22: 23:r 24:rrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rr 4:rr 5:rr 6:rr 7:r 8: 9: 10: 11: 12:WWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22: 23: 24:rrrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rr 4:rr 5:rrr 6:r 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22: 23: 24:rrrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rr 4:rrr 5:r 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22: 23: 24:rrrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rr 3:rrr 4:r 5:rr 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22: 23: 24:rrrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1: 2:rrr 3:r 4:rr 5:rr 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22: 23: 24:rrrr, LongNardy}",
+				"{r after playing    1; Stakes: 1, W canNOT dbl, r canNOT dbl; 1:r 2:r 3:rr 4:rr 5:rr 6:rr 7: 8:r 9: 10: 11: 12:WWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20:W 21: 22: 23: 24:rrrr, LongNardy}",
+			}},
+		// White bears off into BorneOffWhitePip from its home, [13, 18]. The
+		// 1 can't come off the 15 first because the 13 is farther away.
+		example{
+			func(b *Board) {
+				b.Roller = White
+				b.Roll = Roll{6, 1}
+				b.Pips = Points28{}
+				b.Pips[13].Reset(1, White)
+				b.Pips[15].Reset(1, White)
+				b.Pips[BorneOffWhitePip].Reset(13, White)
+				b.Pips[1].Reset(15, Red)
+			},
+			[]string{
+				"{W after playing   61; Stakes: 1, W canNOT dbl, r canNOT dbl; 1:rrrrrrrrrrrrrrr 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, 15 W off, LongNardy}",
+				"{W after playing   16; Stakes: 1, W canNOT dbl, r canNOT dbl; 1:rrrrrrrrrrrrrrr 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13:W 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, 14 W off, LongNardy}",
+			}},
+	}
+	for exNum, ex := range examples {
+		rand.Seed(1)
+		b := NewVariant(LongNardy, true)
+		ex.Initializer(b)
+		if iv := b.Invalidity(IgnoreRollValidity); iv != "" {
+			t.Fatalf("exNum=%d iv=%v", exNum, iv)
+		}
+		candidates := b.LegalContinuations()
+		if len(candidates) != len(ex.continuations) {
+			t.Errorf("exNum=%d candidates=%v", exNum, prettyCandidates(candidates))
+			continue
+		}
+		for i, c := range candidates {
+			if x := c.String(); x != ex.continuations[i] {
+				t.Errorf("exNum=%d i=%d %v", exNum, i, x)
+			}
+		}
+	}
+}
+
+func TestLongNardyFirstTurns(t *testing.T) {
+	b := NewVariant(LongNardy, true)
+	if b.FirstTurns != 2 {
+		t.Fatalf("%d", b.FirstTurns)
+	}
+	for _, c := range b.ContinuationsForRoll(Roll{2, 1}) {
+		if c.FirstTurns != 1 {
+			t.Errorf("%d", c.FirstTurns)
+		}
+	}
+	for expected := uint8(1); ; expected-- {
+		b = b.LegalContinuations()[0]
+		b.TakeTurn(nil, nil)
+		if b.FirstTurns != expected {
+			t.Errorf("%d, not %d", b.FirstTurns, expected)
+		}
+		if expected == 0 {
+			break
+		}
+	}
+	b.TakeTurn(nil, nil)
+	if b.FirstTurns != 0 {
+		t.Errorf("%d", b.FirstTurns)
+	}
+	s := New(false)
+	s.FirstTurns = 1
+	if iv := s.Invalidity(IgnoreRollValidity); iv != "only LongNardy uses FirstTurns, not Standard" {
+		t.Errorf("%v", iv)
+	}
+}
+
+// A six-prime is illegal only at the end of a play, not along the way.
+func TestLongNardyPrimeMidPlay(t *testing.T) {
+	b := NewVariant(LongNardy, true)
+	b.Roller = Red
+	b.Roll = Roll{2, 1}
+	b.Pips = Points28{}
+	b.Pips[12].Reset(15, White)
+	for i := 1; i < 6; i++ {
+		b.Pips[i].Reset(1, Red)
+	}
+	b.Pips[7].Reset(1, Red)
+	b.Pips[BorneOffRedPip].Reset(9, Red)
+	// 7=>6 makes [1, 6], all of White being behind it, but it brings the last
+	// checker home so that the 2 bears off and breaks the prime.
+	const throughPrime = "{r after playing   12; Stakes: 1, W canNOT dbl, r canNOT dbl; 1:r 2: 3:r 4:r 5:r 6:r 7: 8: 9: 10: 11: 12:WWWWWWWWWWWWWWW 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, 10 r off, LongNardy}"
+	found := false
+	for _, c := range b.LegalContinuations() {
+		if c.nardyHasIllegalPrime() {
+			t.Errorf("%v", c)
+		}
+		found = found || c.String() == throughPrime
+	}
+	if !found {
+		t.Errorf("%v is missing", throughPrime)
+	}
+}
+
+func TestLongNardyMars(t *testing.T) {
+	b := NewVariant(LongNardy, true)
+	b.Roller = Red
+	b.Roll = Roll{2, 1}
+	b.Pips = Points28{}
+	b.Pips[12].Reset(15, White)
+	b.Pips[1].Reset(1, Red)
+	b.Pips[2].Reset(1, Red)
+	b.Pips[BorneOffRedPip].Reset(13, Red)
+	next := b.LegalContinuations()
+	if len(next) != 2 || next[0].Pips[BorneOffRedPip].NumCheckers() != 15 {
+		t.Fatalf("next=%v", prettyCandidates(next))
+	}
+	victor, stakes, score := next[0].TakeTurn(nil, nil)
+	if victor != Red || stakes != 2 || score.RedScore != 2 {
+		t.Errorf("victor=%v stakes=%v score=%v", victor, stakes, score)
+	}
+}
+
+func TestPlayGameLongNardy(t *testing.T) {
+	rand.Seed(37)
+	numBoards := 0
+	victor, stakes, _ := NewVariant(LongNardy, true).PlayGame(
+		nil,
+		func(s []*Board) []AnalyzedBoard {
+			return []AnalyzedBoard{AnalyzedBoard{Board: s[rand.Intn(len(s))]}}
+		},
+		func(_ interface{}, b *Board) {
+			numBoards++
+			if iv := b.Invalidity(IgnoreRollValidity); iv != "" {
+				t.Fatalf("invalidity=%v", iv)
+			}
+		},
+		nil,
+		nil)
+	if victor == NoChecker || stakes < 1 || stakes > 2 || numBoards < 50 {
+		t.Errorf("victor=%v stakes=%v numBoards=%v", victor, stakes, numBoards)
+	}
+}
+
+func TestAceyDeucey(t *testing.T) {
+	rand.Seed(5)
+	b := NewVariant(AceyDeucey, true)
+	if bs := b.String(); bs != "{W to play   53; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, WWWWWWWWWWWWWWW to enter, rrrrrrrrrrrrrrr to enter, AceyDeucey}" {
+		t.Fatalf("bs=%v", bs)
+	}
+	if b.PipCount(White) != 375 || b.Racing() || b.PipCountOfFarthestChecker(Red) != 25 {
+		t.Errorf("b=%v", b)
+	}
+
+	type example struct {
+		Initializer   func(*Board)
+		continuations []string
+	}
+	examples := [...]example{
+		// Entering a checker from off the board is like coming off the bar.
+		example{
+			func(b *Board) {
+			},
+			[]string{
+				"{W after playing   53; !dbl; 1: 2: 3:W 4: 5:W 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, WWWWWWWWWWWWW to enter, rrrrrrrrrrrrrrr to enter, AceyDeucey}",
+				"{W after playing   53; !dbl; 1: 2: 3: 4: 5: 6: 7: 8:W 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, WWWWWWWWWWWWWW to enter, rrrrrrrrrrrrrrr to enter, AceyDeucey}",
+			}},
+		// A checker on the bar must enter before one from off the board
+		// can, and hitting works as usual.
+		example{
+			func(b *Board) {
+				b.ToEnter[White] = 13
+				b.Pips[BarWhitePip].Reset(1, White)
+				b.Pips[10].Reset(1, White)
+				b.ToEnter[Red] = 14
+				b.Pips[3].Reset(1, Red)
+			},
+			[]string{
+				"{W after playing   53; !dbl; 1: 2: 3:W 4: 5:W 6: 7: 8: 9: 10:W 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, r on bar, WWWWWWWWWWWW to enter, rrrrrrrrrrrrrr to enter, AceyDeucey}",
+				"{W after playing   53; !dbl; 1: 2: 3:r 4: 5: 6: 7: 8:W 9: 10:W 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, WWWWWWWWWWWWW to enter, rrrrrrrrrrrrrr to enter, AceyDeucey}",
+				"{W after playing   53; !dbl; 1: 2: 3:r 4: 5:W 6: 7: 8: 9: 10: 11: 12: 13:W 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, WWWWWWWWWWWWW to enter, rrrrrrrrrrrrrr to enter, AceyDeucey}",
+				"{W after playing   35; !dbl; 1: 2: 3: 4: 5: 6: 7: 8:W 9: 10:W 11: 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:, r on bar, WWWWWWWWWWWWW to enter, rrrrrrrrrrrrrr to enter, AceyDeucey}",
+				"{W after playing   35; !dbl; 1: 2: 3:W 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15:W 16: 17: 18: 19: 20: 21: 22: 23: 24:, r on bar, WWWWWWWWWWWWW to enter, rrrrrrrrrrrrrr to enter, AceyDeucey}",
+			}},
+		// No bearing off while a checker has yet to enter.
+		example{
+			func(b *Board) {
+				b.Roll = Roll{6, 5}
+				b.ToEnter[White] = 1
+				b.Pips[24].Reset(14, White)
+				b.ToEnter[Red] = 0
+				b.Pips[6].Reset(15, Red)
+			},
+			[]string{
+				"{W after playing   56; !dbl; 1: 2: 3: 4: 5: 6:rrrrrrrrrrrrrrr 7: 8: 9: 10: 11:W 12: 13: 14: 15: 16: 17: 18: 19: 20: 21: 22: 23: 24:WWWWWWWWWWWWWW, AceyDeucey}",
+			}},
+	}
+	for exNum, ex := range examples {
+		rand.Seed(5)
+		b := NewVariant(AceyDeucey, true)
+		ex.Initializer(b)
+		if iv := b.Invalidity(IgnoreRollValidity); iv != "" {
+			t.Fatalf("exNum=%d iv=%v", exNum, iv)
+		}
+		candidates := b.LegalContinuations()
+		if len(candidates) != len(ex.continuations) {
+			t.Errorf("exNum=%d candidates=%v", exNum, prettyCandidates(candidates))
+			continue
+		}
+		for i, c := range candidates {
+			if x := c.String(); x != ex.continuations[i] {
+				t.Errorf("exNum=%d i=%d %v", exNum, i, x)
+			}
+		}
+	}
+}
+
+func TestAceyDeuceyBonus(t *testing.T) {
+	rand.Seed(5)
+	b := NewVariant(AceyDeucey, true)
+	b.Roll = Roll{2, 1}
+	b.ToEnter[White] = 0
+	b.Pips[20].Reset(15, White)
+	candidates := b.LegalContinuations()
+	if len(candidates) != 12 {
+		t.Fatalf("two ways to play <2 1> times six doublets, not %v", prettyCandidates(candidates))
+	}
+	next := candidates[5]
+	if s := next.String(); s != "{W after playing   21 choosing 6666; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20:WWWWWWWWWWWWW 21:W 22:W 23: 24:, rrrrrrrrrrrrrrr to enter, AceyDeucey}" {
+		t.Fatalf("s=%v", s)
+	}
+	if victor, _, _ := next.TakeTurn(nil, nil); victor != NoChecker {
+		t.Fatalf("victor=%v", victor)
+	}
+	if s := next.String(); s != "{W to play 6666 then rolling again; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20:WWWWWWWWWWWWW 21:W 22:W 23: 24:, rrrrrrrrrrrrrrr to enter, AceyDeucey}" {
+		t.Fatalf("s=%v", s)
+	}
+	candidates = next.LegalContinuations()
+	if len(candidates) != 1 || candidates[0].Pips[BorneOffWhitePip].NumWhite() != 4 {
+		t.Fatalf("candidates=%v", prettyCandidates(candidates))
+	}
+	next = candidates[0]
+	next.TakeTurn(
+		func(_ *Board) bool { panic("no doubling in the middle of a turn") },
+		nil)
+	if s := next.String(); s != "{W to play   52; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18: 19: 20:WWWWWWWWW 21:W 22:W 23: 24:, rrrrrrrrrrrrrrr to enter, 4 W off, AceyDeucey}" {
+		t.Fatalf("s=%v", s)
+	}
+}
+
+func TestAceyDeuceyBackgammon(t *testing.T) {
+	b := NewVariant(AceyDeucey, true)
+	b.Roller = White
+	b.Roll = Roll{6, 5}
+	b.ToEnter[White] = 0
+	b.Pips[24].Reset(1, White)
+	b.Pips[BorneOffWhitePip].Reset(14, White)
+	next := b.LegalContinuations()
+	if victor, stakes, _ := next[0].TakeTurn(nil, nil); victor != White || stakes != 3 {
+		t.Errorf("victor=%v stakes=%v", victor, stakes)
+	}
+}
+
+func TestRulesOpeningRoll(t *testing.T) {
+	type example struct {
+		Seed   int64
+		Rules  Rules
+		Stakes int
+		Roll   Roll
+		Roller Checker
+	}
+	examples := [...]example{
+		example{153, Rules{}, 1, Roll{6, 5}, White},
+		example{153, Rules{AutomaticDoubles: 1}, 2, Roll{6, 5}, White},
+		example{153, Rules{AutomaticDoubles: 5}, 8, Roll{6, 5}, White},
+		example{153, Rules{AutomaticDoubles: 5, MaxCube: 4}, 4, Roll{6, 5}, White},
+		example{143, Rules{Opening: OneDieEach}, 1, Roll{5, 4}, Red},
+		example{143, Rules{Opening: OneDieEach, AutomaticDoubles: 5}, 4, Roll{5, 4}, Red},
+	}
+	for _, ex := range examples {
+		rand.Seed(ex.Seed)
+		b := NewGame(Standard, ex.Rules, true)
+		if b.Stakes != ex.Stakes || b.Roll != ex.Roll || b.Roller != ex.Roller || b.Rules != ex.Rules {
+			t.Errorf("ex=%v b=%v", ex, b)
+		}
+		if !b.WhiteCanDouble || !b.RedCanDouble {
+			t.Errorf("ex=%v: automatic doubles leave the cube in the middle: %v", ex, b)
+		}
+	}
+	// A Stakes of 2**63 would overflow.
+	b := New(true)
+	b.Rules.AutomaticDoubles = 63
+	if iv := b.Invalidity(IgnoreRollValidity); iv != "AutomaticDoubles 63 exceed 30" {
+		t.Errorf("%v", iv)
+	}
+	defer func() {
+		if r := recover(); r != "AutomaticDoubles 31 exceed 30" {
+			t.Errorf("%v", r)
+		}
+	}()
+	b.SetRules(Rules{AutomaticDoubles: MaxAutomaticDoubles + 1})
+}
+
+func TestRulesCrawford(t *testing.T) {
+	b := New(true)
+	b.SetRules(Rules{NoCrawfordRule: true})
+	b.SetScore(Score{Goal: 3, WhiteScore: 2})
+	if !b.WhiteCanDouble || !b.RedCanDouble {
+		t.Errorf("b=%v", b)
+	}
+	b = New(true)
+	b.SetScore(Score{Goal: 3, WhiteScore: 2})
+	if b.WhiteCanDouble || b.RedCanDouble {
+		t.Errorf("b=%v", b)
+	}
+}
+
+func TestRulesMaxCube(t *testing.T) {
+	rand.Seed(37)
+	board := New(true)
+	board.SetRules(Rules{MaxCube: 2})
+	board.Stakes = 2
+	board.Roller = Red
+	board.Roll = Roll{6, 6, 6, 6}
+	board.Pips = Points28{}
+	board.Pips[17].Reset(10, Red)
+	board.Pips[16].Reset(10, White)
+	board.Pips[BorneOffWhitePip].Reset(5, White)
+	board.Pips[BorneOffRedPip].Reset(5, Red)
+	assertValidity(board, t)
+	next := board.LegalContinuations()
+	victor, stakes, _ := next[0].TakeTurn(
+		func(_ *Board) bool { t.Errorf("offered a double"); return true },
+		func(_ *Board) bool { return true })
+	if victor != NoChecker || stakes != 0 || next[0].Stakes != 2 {
+		t.Errorf("victor=%v stakes=%v next=%v", victor, stakes, next[0])
+	}
+	board.Stakes = 4
+	if iv := board.Invalidity(IgnoreRollValidity); iv != "Stakes 4 exceed MaxCube 2" {
+		t.Errorf("iv=%v", iv)
+	}
+}
+
+func TestRulesGammons(t *testing.T) {
+	type example struct {
+		Rules  Rules
+		Stakes int
+	}
+	examples := [...]example{
+		example{Rules{}, 6},
+		example{Rules{NoBackgammons: true}, 4},
+		example{Rules{NoGammons: true}, 2},
+		example{Rules{NoGammons: true, NoBackgammons: true}, 2},
+	}
+	for _, ex := range examples {
+		// White wins a backgammon because Red is on the bar.
+		board := New(true)
+		board.SetRules(ex.Rules)
+		board.Stakes = 2
+		board.Roller = White
+		board.Roll = Roll{6, 5}
+		board.Pips = Points28{}
+		board.Pips[24].Reset(1, White)
+		board.Pips[BorneOffWhitePip].Reset(14, White)
+		board.Pips[BarRedPip].Reset(1, Red)
+		board.Pips[12].Reset(14, Red)
+		assertValidity(board, t)
+		next := board.LegalContinuations()
+		victor, stakes, _ := next[0].TakeTurn(nil, nil)
+		if victor != White || stakes != ex.Stakes {
+			t.Errorf("ex=%v victor=%v stakes=%v", ex, victor, stakes)
+		}
+	}
+}
+
+func TestPosition(t *testing.T) {
+	if p := (Position{}).Pips(); p[BorneOffWhitePip] != NewPoint(15, White) || p[BorneOffRedPip] != NewPoint(15, Red) {
+		t.Errorf("the zero Position is not an empty board: %v", p)
+	}
+	b := New(true)
+	b.Pips[BarWhitePip].Reset(15, White)
+	b.Pips[BarRedPip].Reset(1, Red)
+	b.Pips[1].Reset(0, White)
+	b.Pips[12].Reset(0, White)
+	b.Pips[17].Reset(0, White)
+	b.Pips[19].Reset(0, White)
+	b.Pips[24].Subtract()
+	b.Pips[6].Reset(15-1-1, Red)
+	b.Pips[8].Reset(0, Red)
+	b.Pips[13].Reset(0, Red)
+	assertValidity(b, t)
+	if p := b.Position(); p.Pips() != b.Pips || p.Point(24) != NewPoint(1, Red) || p.Bar(White) != 15 {
+		t.Errorf("%v round-trips to %v", b.Pips, p.Pips())
+	}
+	if New(true).Position() == b.Position() {
+		t.Errorf("oops")
+	}
+}
+
+// The Position-based move generator must agree with the Board-based one, order
+// and all.
+func TestPositionQuasiLegalContinuations(t *testing.T) {
+	rand.Seed(37)
+	numBoards := 0
+	for game := 0; game < 20; game++ {
+		b := New(true)
+		for victor := NoChecker; victor == NoChecker; numBoards++ {
+			want := b.boardQuasiLegalContinuations()
+			got := b.positionQuasiLegalContinuations()
+			if len(got) != len(want) {
+				t.Fatalf("b=%v got %d continuations, want %d", b, len(got), len(want))
+			}
+			for i := range got {
+				if got[i].String() != want[i].String() || !got[i].Equals(*want[i]) {
+					t.Fatalf("b=%v i=%d got %v want %v", b, i, got[i], want[i])
+				}
+			}
+			candidates := b.LegalContinuations()
+			b = candidates[rand.Intn(len(candidates))]
+			victor, _, _ = b.TakeTurn(nil, nil)
+		}
+	}
+	if numBoards < 500 {
+		t.Errorf("numBoards=%d", numBoards)
+	}
+}
+
+func TestHash(t *testing.T) {
+	rand.Seed(37)
+	b := New(true)
+	if h := (&Board{}).Hash(); h != 0 {
+		t.Errorf("the empty board hashes to %x", h)
+	}
+	c := *b
+	if b.Hash() != c.Hash() {
+		t.Errorf("oops")
+	}
+	c.Roll = Roll{c.Roll[1], c.Roll[0]}
+	if !c.Equals(*b) || b.Hash() != c.Hash() {
+		t.Errorf("<6 4> and <4 6> differ")
+	}
+	c.Roller = c.Roller.OtherColor()
+	if b.Hash() == c.Hash() {
+		t.Errorf("the Roller is ignored")
+	}
+	c = *b
+	c.Roll = Roll{6, 6, 6, 6}
+	if b.Hash() == c.Hash() {
+		t.Errorf("the Roll is ignored")
+	}
+	// Hashes are stable from run to run so that they can be persisted.
+	if h := b.Hash(); h != 0x8966d24c395b4f34 {
+		t.Errorf("b=%v b.Hash()=%#x", b, h)
+	}
+}
+
+// The move generator's incremental hashes must match Board.Hash().
+func TestHashIncremental(t *testing.T) {
+	rand.Seed(37)
+	for game := 0; game < 10; game++ {
+		b := New(true)
+		for victor := NoChecker; victor == NoChecker; {
+			s := nodeSearch{visited: make(map[uint64]nodeKey)}
+			s.start(b)
+			candidates := b.positionQuasiLegalContinuations()
+			for i, leaf := range s.leaves {
+				if h := candidates[i].Hash(); h != leaf.Hash {
+					t.Fatalf("b=%v candidate=%v Hash()=%x leaf.Hash=%x", b, candidates[i], h, leaf.Hash)
+				}
+			}
+			candidates = b.LegalContinuations()
+			b = candidates[rand.Intn(len(candidates))]
+			victor, _, _ = b.TakeTurn(nil, nil)
+		}
+	}
+}
+
+// Likewise for the variants that move whole Boards.
+func TestHashIncrementalBoards(t *testing.T) {
+	rand.Seed(37)
+	for game := 0; game < 10; game++ {
+		variant := AceyDeucey
+		if game%2 == 1 {
+			variant = LongNardy
+		}
+		b := NewVariant(variant, true)
+		for victor := NoChecker; victor == NoChecker; {
+			nodes := b.nardyContinuations(b.nardyHeadAllowance(), b.Hash())
+			if variant == AceyDeucey {
+				nodes = b.boardContinuations()
+			}
+			for _, n := range nodes {
+				if h := n.Board.Hash(); h != n.Hash {
+					t.Fatalf("b=%v c=%v Hash()=%x n.Hash=%x", b, n.Board, h, n.Hash)
+				}
+			}
+			candidates := b.LegalContinuations()
+			b = candidates[rand.Intn(len(candidates))]
+			victor, _, _ = b.TakeTurn(nil, nil)
+		}
+	}
+}
+
+func TestForEachLegalContinuation(t *testing.T) {
+	rand.Seed(37)
+	arena := &Arena{}
+	for game := 0; game < 11; game++ {
+		variant := Standard
+		if game == 10 {
+			variant = AceyDeucey
+		}
+		b := NewVariant(variant, true)
+		for victor := NoChecker; victor == NoChecker; {
+			candidates := b.LegalContinuations()
+			i := 0
+			b.ForEachLegalContinuation(arena, func(c *Board) bool {
+				if i >= len(candidates) || c.String() != candidates[i].String() {
+					t.Fatalf("b=%v i=%d c=%v candidates=%v", b, i, c, candidates)
+				}
+				i++
+				return true
+			})
+			if i != len(candidates) {
+				t.Fatalf("b=%v visited %d of %d", b, i, len(candidates))
+			}
+			b = candidates[rand.Intn(len(candidates))]
+			victor, _, _ = b.TakeTurn(nil, nil)
+		}
+	}
+}
+
+func TestForEachLegalContinuationStopsEarly(t *testing.T) {
+	rand.Seed(37)
+	b := New(true)
+	num := 0
+	b.ForEachLegalContinuation(&Arena{}, func(c *Board) bool {
+		num++
+		return num < 3
+	})
+	if num != 3 || len(b.LegalContinuations()) <= 3 {
+		t.Errorf("num=%d", num)
+	}
+}
+
+func setUpManyPossibilities(board *Board) {
+	board.Roller = White
+	board.Roll = Roll{6, 6, 6, 6}
+	board.Pips = Points28{}
+	board.Pips[7].Reset(3, White)
+	board.Pips[6].Reset(4, White)
+	board.Pips[5].Reset(4, White)
+	board.Pips[4].Reset(4, White)
+	board.Pips[BarRedPip].Reset(1, Red)
+	board.Pips[BorneOffRedPip].Reset(14, Red)
+}
+
+func TestForEachLegalContinuationAllocations(t *testing.T) {
+	rand.Seed(37)
+	arena := &Arena{}
+	for _, b := range [...]*Board{New(true), New(true), New(true)} {
+		setUpManyPossibilities(b)
+		num := 0
+		visit := func(c *Board) bool {
+			num++
+			return true
+		}
+		b.ForEachLegalContinuation(arena, visit) // warms up the Arena
+		if allocs := testing.AllocsPerRun(100, func() { b.ForEachLegalContinuation(arena, visit) }); allocs != 0 {
+			t.Errorf("allocs=%v", allocs)
+		}
+		if num != 96*102 { // AllocsPerRun warms up, too
+			t.Errorf("num=%d", num)
+		}
+	}
+	// And a board with checkers on the bar:
+	b := New(true)
+	b.Roller = White
+	b.Roll = Roll{6, 6, 6, 6}
+	b.Pips[19].Reset(0, White)
+	b.Pips[BarWhitePip].Reset(5, White)
+	b.Pips[6].Reset(1, Red)
+	b.Pips[BarRedPip].Reset(4, Red)
+	visit := func(c *Board) bool { return true }
+	b.ForEachLegalContinuation(arena, visit)
+	if allocs := testing.AllocsPerRun(100, func() { b.ForEachLegalContinuation(arena, visit) }); allocs != 0 {
+		t.Errorf("allocs=%v", allocs)
+	}
+}
+
+func BenchmarkForEachLegalContinuationWith96Possibilities(b *testing.B) {
+	board := New(false)
+	setUpManyPossibilities(board)
+	arena := &Arena{}
+	num := 0
+	visit := func(c *Board) bool {
+		num++
+		return true
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		board.ForEachLegalContinuation(arena, visit)
+	}
+	if num != 96*b.N {
+		panic(num)
+	}
+}
+
+func TestAllRolls(t *testing.T) {
+	total := 0
+	seen := map[Roll]bool{}
+	for _, wr := range AllRolls() {
+		if iv := wr.Roll.invalidity(); iv != "" || seen[wr.Roll] {
+			t.Errorf("wr=%v iv=%v", wr, iv)
+		}
+		seen[wr.Roll] = true
+		total += wr.Weight
+	}
+	if total != 36 || len(seen) != 21 {
+		t.Errorf("total=%d", total)
+	}
+	all := AllRolls()
+	if all[0] != (WeightedRoll{Roll{6, 6, 6, 6}, 1}) || all[1] != (WeightedRoll{Roll{6, 5}, 2}) || all[20].Probability() != 1.0/36 {
+		t.Errorf("all=%v", all)
+	}
+}
+
+func TestContinuationsForRoll(t *testing.T) {
+	rand.Seed(37)
+	b := New(true)
+	b = b.LegalContinuations()[0]
+	if b.String() != "{W after playing   64; !dbl; 1: 2: 3: 4: 5:W 6:rrrrr 7:W 8:rrr 9: 10: 11: 12:WWWWW 13:rrrrr 14: 15: 16: 17:WWW 18: 19:WWWWW 20: 21: 22: 23: 24:rr}" {
+		t.Fatalf("b=%v", b)
+	}
+	before := *b
+	rand.Seed(37)
+	continuations := b.ContinuationsForRoll(Roll{5, 5, 5, 5})
+	if x := rand.Int63(); x != rand.New(rand.NewSource(37)).Int63() {
+		t.Errorf("touched the RNG")
+	}
+	if !before.Equals(*b) {
+		t.Errorf("mutated b: %v", b)
+	}
+	expected := *b
+	expected.Roller = Red
+	expected.Roll = Roll{5, 5, 5, 5}
+	expected.RollUsed = Roll{}
+	want := expected.LegalContinuations()
+	if len(continuations) != len(want) || len(want) < 2 {
+		t.Fatalf("len=%d want %d", len(continuations), len(want))
+	}
+	for i := range want {
+		if !want[i].Equals(*continuations[i]) || continuations[i].Roller != Red {
+			t.Errorf("i=%d %v != %v", i, continuations[i], want[i])
+		}
+	}
+
+	// The same Roller goes again in AceyDeucey after a bonus doublet.
+	a := NewVariant(AceyDeucey, true)
+	a.Roller = White
+	a.RollAgain = true
+	a.Roll = Roll{}
+	a.RollUsed = Roll{4, 4, 4, 4}
+	for _, c := range a.ContinuationsForRoll(Roll{2, 1}) {
+		if c.Roller != White || c.RollAgain || c.Bonus == ZeroDie {
+			t.Errorf("c=%v", c)
+		}
+	}
+
+	// When Red can't enter, the only continuation is the pooled copy of a
+	// that we would otherwise return to the pool.
+	a = NewVariant(AceyDeucey, true)
+	a.Roller = White
+	a.Roll = Roll{}
+	a.RollUsed = Roll{6, 5}
+	a.ToEnter[White] = 0
+	for i := 19; i < 25; i++ {
+		a.Pips[i].Reset(2, White)
+	}
+	a.Pips[18].Reset(3, White)
+	stuck := a.ContinuationsForRoll(Roll{6, 5})
+	if len(stuck) != 1 {
+		t.Fatalf("stuck=%v", stuck)
+	}
+	s := stuck[0].String()
+	for i := 0; i < 100; i++ {
+		a.ContinuationsForRoll(Roll{4, 3})
+	}
+	if x := stuck[0].String(); x != s || x != "{r to play   65; !dbl; 1: 2: 3: 4: 5: 6: 7: 8: 9: 10: 11: 12: 13: 14: 15: 16: 17: 18:WWW 19:WW 20:WW 21:WW 22:WW 23:WW 24:WW, rrrrrrrrrrrrrrr to enter, AceyDeucey}" {
+		t.Errorf("%v became %v", s, x)
+	}
+}
+
+func TestShotsByDistance(t *testing.T) {
+	// The number of rolls that hit a blot with a single checker that far
+	// away on an open board, direct and indirect.
+	expected := map[int][2]int{
+		1: {11, 0}, 2: {11, 1}, 3: {11, 3}, 4: {11, 4}, 5: {11, 4}, 6: {11, 6},
+		7: {0, 6}, 8: {0, 6}, 9: {0, 5}, 10: {0, 3}, 11: {0, 2}, 12: {0, 3},
+		13: {0, 0}, 14: {0, 0}, 15: {0, 1}, 16: {0, 1}, 17: {0, 0}, 18: {0, 1},
+		19: {0, 0}, 20: {0, 1}, 21: {0, 0}, 22: {0, 0}, 23: {0, 0}, 24: {0, 1},
+	}
+	for distance := 1; distance <= 24; distance++ {
+		b := New(true)
+		b.Pips = Points28{}
+		b.Roller = White
+		b.Pips[1].Reset(1, White)
+		b.Pips[BorneOffWhitePip].Reset(14, White)
+		b.Pips[BorneOffRedPip].Reset(14, Red)
+		if distance == 24 {
+			b.Pips[BarRedPip].Reset(1, Red)
+		} else {
+			b.Pips[1+distance].Reset(1, Red)
+		}
+		assertValidity(b, t)
+		shots, total := b.Shots(White)
+		want := expected[distance]
+		if len(shots) != 1 || shots[0] != (BlotShots{1, want[0], want[1]}) || total != want[0]+want[1] {
+			t.Errorf("distance=%d shots=%v total=%d want %v", distance, shots, total, want)
+		}
+	}
+}
+
+func TestShots(t *testing.T) {
+	type example struct {
+		Initializer func(*Board)
+		Player      Checker
+		Shots       []BlotShots
+		Total       int
+	}
+	examples := [...]example{
+		example{
+			func(b *Board) {
+			},
+			White,
+			nil,
+			0},
+		example{
+			// White's points block every indirect shot but <3 3>.
+			func(b *Board) {
+				b.Pips = Points28{}
+				b.Pips[7].Reset(1, White)
+				b.Pips[8].Reset(2, White)
+				b.Pips[9].Reset(2, White)
+				b.Pips[10].Reset(1, White)
+				b.Pips[11].Reset(2, White)
+				b.Pips[12].Reset(2, White)
+				b.Pips[19].Reset(5, White)
+				b.Pips[13].Reset(15, Red)
+			},
+			White,
+			[]BlotShots{BlotShots{7, 11, 1}, BlotShots{10, 11, 0}},
+			20},
+		example{
+			// Two Red checkers on the bar can hit only by entering, unless
+			// the roll is a doublet.
+			func(b *Board) {
+				b.Pips = Points28{}
+				b.Pips[20].Reset(1, White)
+				b.Pips[15].Reset(1, White)
+				b.Pips[1].Reset(13, White)
+				b.Pips[BarRedPip].Reset(2, Red)
+				b.Pips[6].Reset(13, Red)
+			},
+			White,
+			[]BlotShots{BlotShots{15, 0, 1}, BlotShots{20, 11, 0}},
+			11},
+		example{
+			// One Red checker on the bar must enter first, and then anything
+			// may hit.
+			func(b *Board) {
+				b.Pips = Points28{}
+				b.Pips[20].Reset(1, White)
+				b.Pips[15].Reset(1, White)
+				b.Pips[1].Reset(13, White)
+				b.Pips[BarRedPip].Reset(1, Red)
+				b.Pips[16].Reset(14, Red)
+			},
+			White,
+			[]BlotShots{BlotShots{15, 11, 3}, BlotShots{20, 11, 4}},
+			24},
+		example{
+			func(b *Board) {
+				*b = *NewVariant(LongNardy, true)
+			},
+			White,
+			nil,
+			0},
+	}
+	for i, ex := range examples {
+		rand.Seed(37)
+		b := New(true)
+		ex.Initializer(b)
+		assertValidity(b, t)
+		shots, total := b.Shots(ex.Player)
+		if fmt.Sprint(shots) != fmt.Sprint(ex.Shots) || total != ex.Total {
+			t.Errorf("i=%d b=%v shots=%v total=%d", i, b, shots, total)
+		}
+	}
+}
+
+// Shots() only errs by counting a hit that the must-play rule forbids, so it
+// never counts fewer than enumerating the opponent's legal continuations.
+func TestShotsAgainstLegalContinuations(t *testing.T) {
+	rand.Seed(37)
+	numBoards, numMismatches := 0, 0
+	for game := 0; game < 10; game++ {
+		b := New(true)
+		for victor := NoChecker; victor == NoChecker; numBoards++ {
+			player := b.Roller
+			bar := BarWhitePip
+			if player == Red {
+				bar = BarRedPip
+			}
+			var exact [25]int
+			for _, wr := range AllRolls() {
+				c := *b
+				c.Roller = player.OtherColor()
+				c.Roll = wr.Roll
+				c.RollUsed = Roll{}
+				var hit [25]bool
+				for _, n := range c.LegalContinuations() {
+					if n.Pips[bar] == b.Pips[bar] {
+						continue
+					}
+					for i := 1; i < 25; i++ {
+						if b.Pips[i].Num(player) == 1 && n.Pips[i].Num(player) == 0 {
+							hit[i] = true
+						}
+					}
+				}
+				for i, h := range hit {
+					if h {
+						exact[i] += wr.Weight
+					}
+				}
+			}
+			shots, _ := b.Shots(player)
+			mismatch := false
+			for _, s := range shots {
+				if s.Total() < exact[s.Point] {
+					t.Fatalf("b=%v shots=%v exact=%v", b, shots, exact)
+				}
+				mismatch = mismatch || s.Total() != exact[s.Point]
+			}
+			if mismatch {
+				numMismatches++
+			}
+			candidates := b.LegalContinuations()
+			b = candidates[rand.Intn(len(candidates))]
+			victor, _, _ = b.TakeTurn(nil, nil)
+		}
+	}
+	if numMismatches*100 > numBoards {
+		t.Errorf("%d mismatches out of %d", numMismatches, numBoards)
+	}
+}
+
+func TestPerspective(t *testing.T) {
+	rand.Seed(37)
+	b := New(true)
+	start := [26]int{6: 5, 8: 3, 13: 5, 24: 2}
+	for _, player := range players {
+		v := b.Perspective(player)
+		if v.Me != player || v.Mine != start || v.Theirs != start {
+			t.Errorf("v=%+v", v)
+		}
+		if v.TheirsOn(19) != 5 || !v.Blocked(19) || v.Blocked(7) || v.Blocked(18) {
+			t.Errorf("v=%+v", v)
+		}
+		if mine, theirs := v.PipCounts(); mine != 167 || theirs != 167 {
+			t.Errorf("mine=%d theirs=%d", mine, theirs)
+		}
+		if o := v.Opponent(); o.Me != player.OtherColor() || o.Mine != v.Theirs || o.Theirs != v.Mine {
+			t.Errorf("o=%+v", o)
+		}
+	}
+	b.Pips[BarRedPip].Reset(1, Red)
+	b.Pips[24].Reset(1, Red)
+	b.Pips[3].Reset(1, White)
+	b.Pips[1].Reset(1, White)
+	b.Pips[BorneOffWhitePip].Reset(1, White)
+	v := b.Perspective(White)
+	if v.Mine != [26]int{0: 1, 6: 5, 8: 3, 13: 5, 22: 1, 24: 1} || v.Theirs != [26]int{6: 5, 8: 3, 13: 5, 24: 1, 25: 1} {
+		t.Errorf("v=%+v", v)
+	}
+	if v.TheirsOn(1) != 1 || v.Blocked(1) {
+		t.Errorf("v=%+v", v)
+	}
+	n := NewVariant(LongNardy, true)
+	v = n.Perspective(Red)
+	if v.Mine != [26]int{24: 15} || v.Theirs != [26]int{24: 15} {
+		t.Errorf("v=%+v", v)
+	}
+	if v.Opposite(24) != 12 || v.Opposite(12) != 24 || v.TheirsOn(12) != 15 || !v.Blocked(12) || v.Blocked(24) {
+		t.Errorf("v=%+v", v)
+	}
+	if mine, theirs := v.PipCounts(); mine != n.PipCount(Red) || theirs != n.PipCount(White) {
+		t.Errorf("mine=%d theirs=%d", mine, theirs)
+	}
+	a := NewVariant(AceyDeucey, true)
+	if v = a.Perspective(White); v.Mine != [26]int{25: 15} || v.Theirs != [26]int{25: 15} {
+		t.Errorf("v=%+v", v)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	rand.Seed(37)
+	numBoards := 0
+	for _, variant := range [...]Variant{Standard, LongNardy, AceyDeucey} {
+		for game := 0; game < 4; game++ {
+			b := NewVariant(variant, true)
+			b.SetScore(Score{Goal: 5, WhiteScore: 3, RedScore: 1})
+			for victor := NoChecker; victor == NoChecker; numBoards++ {
+				m := b.Mirror()
+				if iv := m.Invalidity(EnforceRollValidity); iv != "" {
+					t.Fatalf("b=%v m=%v invalidity=%v", b, m, iv)
+				}
+				if mm := m.Mirror(); !mm.Equals(*b) || mm.String() != b.String() {
+					t.Fatalf("b=%v mm=%v", b, mm)
+				}
+				v, w := b.Perspective(White), m.Perspective(Red)
+				if v.Mine != w.Mine || v.Theirs != w.Theirs || m.Roller == b.Roller {
+					t.Fatalf("b=%v m=%v", b, m)
+				}
+				if b.PipCount(White) != m.PipCount(Red) || b.PipCount(Red) != m.PipCount(White) {
+					t.Fatalf("b=%v m=%v", b, m)
+				}
+				if m.MatchScore.WhiteScore != 1 || m.MatchScore.RedScore != 3 {
+					t.Fatalf("m=%v", m)
+				}
+				candidates := b.LegalContinuations()
+				if variant != AceyDeucey {
+					mirrored := m.LegalContinuations()
+					if len(mirrored) != len(candidates) {
+						t.Fatalf("b=%v len(candidates)=%d len(mirrored)=%d", b, len(candidates), len(mirrored))
+					}
+					for _, c := range candidates {
+						if !c.Mirror().equalsAny(mirrored) {
+							t.Fatalf("b=%v c=%v has no mirror image among %v", b, c, mirrored)
+						}
+					}
+				}
+				b = candidates[rand.Intn(len(candidates))]
+				victor, _, _ = b.TakeTurn(nil, nil)
+			}
+		}
+	}
+	if numBoards < 500 {
+		t.Errorf("numBoards=%d", numBoards)
+	}
+}
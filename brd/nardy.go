@@ -0,0 +1,232 @@
+package brd
+
+// Long Nardy uses the same 24 points but both players travel in the same
+// direction. Red keeps its usual route from 24 down to 1 and bears off into
+// BorneOffRedPip. White starts on the 12 point and runs from 12 down to 1 and
+// then from 24 down to 13, so White's home is [13, 18] and White bears off
+// into BorneOffWhitePip. Each player starts with all 15 checkers on the first
+// point of its route, the head.
+//
+// The rules that differ from standard backgammon:
+//   - There is no hitting and thus no bar. A single checker holds a point.
+//   - Only one checker may leave the head per turn, except that on a player's
+//     first turn <6 6>, <4 4>, and <3 3> let two checkers leave (one could not
+//     get past the opponent otherwise).
+//   - You may not build a six-prime unless at least one of your opponent's
+//     checkers is already past it.
+//   - A win is worth one point (oin), or two (mars) if the loser has not borne
+//     off any checkers. There is no doubling cube.
+//
+// As in standard backgammon you must use as much of the roll as you can, and
+// the larger die if you can use only one.
+
+// Returns how far along player's route the given point in [1, 24] lies. The
+// head is 0; the last point before bearing off is 23.
+func nardyDistance(player Checker, point int) int {
+	if player == Red {
+		return 24 - point
+	}
+	if point <= 12 {
+		return 12 - point
+	}
+	return 36 - point
+}
+
+// The inverse of nardyDistance.
+func nardyPoint(player Checker, distance int) int {
+	if player == Red {
+		return 24 - distance
+	}
+	if distance < 12 {
+		return 12 - distance
+	}
+	return 36 - distance
+}
+
+func nardyHead(player Checker) int {
+	return nardyPoint(player, 0)
+}
+
+func borneOffPip(player Checker) int {
+	if player == White {
+		return BorneOffWhitePip
+	}
+	return BorneOffRedPip
+}
+
+// Two checkers may leave the head on the Roller's first turn if the roll is
+// one of these doublets. Having all fifteen on the head doesn't make it the
+// first turn: the Roller may have been unable to move before.
+func (b *Board) nardyHeadAllowance() int {
+	if b.FirstTurns > 0 && b.Roll[0] == b.Roll[1] {
+		switch b.Roll[0] {
+		case 3, 4, 6:
+			return 2
+		}
+	}
+	return 1
+}
+
+func (b *Board) nardyCanBearOff() bool {
+	for distance := 0; distance < 18; distance++ {
+		if b.Pips[nardyPoint(b.Roller, distance)].Num(b.Roller) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// targetPip is undefined unless can is true
+func (b *Board) nardyCanMoveChecker(distance int, die Die) (targetPip int, can bool) {
+	target := distance + int(die)
+	if target >= 24 {
+		goodEnough := true
+		if target != 24 {
+			for d := 18; d < distance; d++ {
+				if b.Pips[nardyPoint(b.Roller, d)].Num(b.Roller) > 0 {
+					goodEnough = false
+					break
+				}
+			}
+		}
+		if goodEnough {
+			can = b.nardyCanBearOff()
+			targetPip = borneOffPip(b.Roller)
+		}
+		return
+	}
+	targetPip = nardyPoint(b.Roller, target)
+	can = b.Pips[targetPip].Num(b.Roller.OtherColor()) == 0
+	return
+}
+
+// A six-prime held by the Roller at the end of its play is illegal unless one
+// of the opponent's checkers is already past it. The 24 points form a cycle for the purposes of
+// primes because White's route runs from 1 to 24.
+func (b *Board) nardyHasIllegalPrime() bool {
+	opponent := b.Roller.OtherColor()
+	if b.Pips[borneOffPip(opponent)].NumCheckers() > 0 {
+		return false
+	}
+	farthest := -1
+	for distance := 23; distance >= 0; distance-- {
+		if b.Pips[nardyPoint(opponent, distance)].Num(opponent) > 0 {
+			farthest = distance
+			break
+		}
+	}
+	run := 0
+	// Going around twice finds primes that straddle the cycle's seam.
+	for k := 0; k < 48; k++ {
+		distance := k % 24
+		if b.Pips[nardyPoint(opponent, distance)].Num(b.Roller) == 0 {
+			run = 0
+			continue
+		}
+		run++
+		if run < 6 || k-5 >= 24 {
+			continue
+		}
+		front := distance
+		if k >= 24 {
+			front = 23 // nothing on the board is past a prime that straddles the seam
+		}
+		if farthest <= front {
+			return true
+		}
+	}
+	return false
+}
+
+// Like quasiLegalContinuations but for LongNardy.
+func (b *Board) nardyQuasiLegalContinuations() []*Board {
+	continuations := b.nardyContinuations(b.nardyHeadAllowance(), b.Hash())
+	if len(continuations) == 0 {
+		return []*Board{b}
+	}
+	return boardsOf(continuations)
+}
+
+// Returns len(continuations)==0 when no play that goes beyond b is legal, in
+// which case b itself is the answer unless it holds an illegal prime. hash is
+// b.Hash().
+//
+// A prime is illegal only at the end of a play, so one made and broken again
+// in the middle of the turn is fine, and a play that can't go on without
+// making one may stop early. LegalContinuations() then insists on the plays
+// that use the most dice.
+func (b *Board) nardyContinuations(headAllowance int, hash uint64) (continuations []boardNode) {
+	remainingDice := b.Roll.Dice()
+	if len(remainingDice) == 0 {
+		return
+	}
+	head := nardyHead(b.Roller)
+	for _, die := range remainingDice {
+		for distance := 0; distance < 24; distance++ {
+			i := nardyPoint(b.Roller, distance)
+			if b.Pips[i].Num(b.Roller) == 0 || (i == head && headAllowance == 0) {
+				continue
+			}
+			targetPip, can := b.nardyCanMoveChecker(distance, die)
+			if !can {
+				continue
+			}
+			next := boardPool.Get().(*Board)
+			*next = *b
+			nextHash := hash ^ rollHashDelta(next.Roll, die) ^ next.pipHash(i) ^ next.pipHash(targetPip)
+			next.Pips[i].Subtract()
+			next.Pips[targetPip].Add(b.Roller)
+			nextHash ^= next.pipHash(i) ^ next.pipHash(targetPip)
+			next.Roll = next.Roll.Use(die, &next.RollUsed)
+			nextAllowance := headAllowance
+			if i == head {
+				nextAllowance--
+			}
+			cont := next.nardyContinuations(nextAllowance, nextHash)
+			switch {
+			case len(cont) > 0:
+				boardPool.Put(next)
+				continuations = append(continuations, cont...)
+			case next.nardyHasIllegalPrime():
+				boardPool.Put(next)
+			default:
+				continuations = append(continuations, boardNode{next, nextHash})
+			}
+		}
+	}
+	if len(continuations) != 0 {
+		continuations = uniqueContinuations(continuations)
+	}
+	return
+}
+
+// assumes victory for b.Roller.
+func (b *Board) nardyVictorMultiplier() int {
+	if b.Pips[borneOffPip(b.Roller.OtherColor())].NumCheckers() == 0 {
+		return 2
+	}
+	return 1
+}
+
+func (b *Board) nardyPipCount(player Checker) (result int) {
+	for distance := 0; distance < 24; distance++ {
+		result += (24 - distance) * b.Pips[nardyPoint(player, distance)].Num(player)
+	}
+	return
+}
+
+func (b *Board) nardyPipCountOfFarthestChecker(player Checker) int {
+	for distance := 0; distance < 24; distance++ {
+		if b.Pips[nardyPoint(player, distance)].Num(player) > 0 {
+			return 24 - distance
+		}
+	}
+	return -1
+}
+
+func (b *Board) nardyNumCheckersHome(player Checker) (result int) {
+	for distance := 18; distance < 24; distance++ {
+		result += b.Pips[nardyPoint(player, distance)].Num(player)
+	}
+	return
+}
@@ -0,0 +1,128 @@
+package brd
+
+// A Board as one player sees it. Each side's checkers are indexed by that
+// side's own point numbers: [1, 24] are the points, counting from the point
+// nearest to bearing off, so [1, 6] is the side's home board; 0 is the
+// checkers borne off; 25 is the bar plus, in AceyDeucey, the checkers yet to
+// enter. A point's number is also the number of pips a checker there needs to
+// bear off.
+//
+// With a PerspectiveView you can write an evaluator once instead of once for
+// White and again, mirrored, for Red.
+type PerspectiveView struct {
+	Me      Checker
+	Variant Variant
+	Mine    [26]int
+	Theirs  [26]int
+}
+
+func (b *Board) Perspective(me Checker) PerspectiveView {
+	v := PerspectiveView{Me: me, Variant: b.Variant}
+	them := me.OtherColor()
+	for i := 1; i < 25; i++ {
+		p := b.Pips[i]
+		if n := p.Num(me); n > 0 {
+			v.Mine[me.pointNumber(b.Variant, i)] = n
+		} else if n := p.Num(them); n > 0 {
+			v.Theirs[them.pointNumber(b.Variant, i)] = n
+		}
+	}
+	v.Mine[0] = b.Pips[borneOffPip(me)].NumCheckers()
+	v.Theirs[0] = b.Pips[borneOffPip(them)].NumCheckers()
+	v.Mine[25] = b.Pips[me.barPip()].NumCheckers() + int(b.ToEnter[me])
+	v.Theirs[25] = b.Pips[them.barPip()].NumCheckers() + int(b.ToEnter[them])
+	return v
+}
+
+// The same Board as the opponent sees it.
+func (v PerspectiveView) Opponent() PerspectiveView {
+	return PerspectiveView{Me: v.Me.OtherColor(), Variant: v.Variant, Mine: v.Theirs, Theirs: v.Mine}
+}
+
+// Returns the opponent's number for my point i in [1, 24].
+func (v *PerspectiveView) Opposite(i int) int {
+	if v.Variant == LongNardy {
+		// Both sides travel the same way, half a board apart.
+		return (i+11)%24 + 1
+	}
+	return 25 - i
+}
+
+// The opponent's checkers on my point i in [1, 24].
+func (v *PerspectiveView) TheirsOn(i int) int {
+	return v.Theirs[v.Opposite(i)]
+}
+
+// Whether the opponent has made my point i in [1, 24]. In LongNardy a single
+// checker holds a point.
+func (v *PerspectiveView) Blocked(i int) bool {
+	if v.Variant == LongNardy {
+		return v.TheirsOn(i) > 0
+	}
+	return v.TheirsOn(i) > 1
+}
+
+// Like Board.PipCount() for both sides.
+func (v *PerspectiveView) PipCounts() (mine, theirs int) {
+	for i := 1; i < 26; i++ {
+		mine += i * v.Mine[i]
+		theirs += i * v.Theirs[i]
+	}
+	return
+}
+
+// Returns player's number for the point i in [1, 24].
+func (player Checker) pointNumber(variant Variant, i int) int {
+	if variant == LongNardy {
+		return 24 - nardyDistance(player, i)
+	}
+	if player == White {
+		return 25 - i
+	}
+	return i
+}
+
+func (player Checker) barPip() int {
+	if player == White {
+		return BarWhitePip
+	}
+	return BarRedPip
+}
+
+// Returns a copy of b with the colors swapped: White's checkers become Red's
+// on the corresponding points and vice versa, and so do the Roller, the
+// checkers yet to enter, the cube and the MatchScore. b.Mirror().Mirror()
+// equals b, and b.Mirror().Perspective(Red) equals b.Perspective(White).
+func (b *Board) Mirror() *Board {
+	m := *b
+	m.Roller = b.Roller.OtherColor()
+	m.ToEnter[White], m.ToEnter[Red] = b.ToEnter[Red], b.ToEnter[White]
+	m.WhiteCanDouble, m.RedCanDouble = b.RedCanDouble, b.WhiteCanDouble
+	m.MatchScore.WhiteScore, m.MatchScore.RedScore = b.MatchScore.RedScore, b.MatchScore.WhiteScore
+	m.Pips = Points28{}
+	for i := 1; i < 25; i++ {
+		p := b.Pips[i]
+		switch {
+		case p.NumWhite() > 0:
+			m.Pips[Red.boardPoint(b.Variant, White.pointNumber(b.Variant, i))] = -p
+		case p.NumRed() > 0:
+			m.Pips[White.boardPoint(b.Variant, Red.pointNumber(b.Variant, i))] = -p
+		}
+	}
+	m.Pips[BorneOffRedPip] = -b.Pips[BorneOffWhitePip]
+	m.Pips[BorneOffWhitePip] = -b.Pips[BorneOffRedPip]
+	m.Pips[BarRedPip] = -b.Pips[BarWhitePip]
+	m.Pips[BarWhitePip] = -b.Pips[BarRedPip]
+	return &m
+}
+
+// The inverse of pointNumber.
+func (player Checker) boardPoint(variant Variant, number int) int {
+	if variant == LongNardy {
+		return nardyPoint(player, 24-number)
+	}
+	if player == White {
+		return 25 - number
+	}
+	return number
+}
@@ -0,0 +1,323 @@
+package brd
+
+// A Position is a compact, comparable encoding of where the checkers are: five
+// bits for each of the 24 points (a Red flag and a count) and four bits for
+// each bar. Borne-off checkers are whatever is left of the fifteen, so a
+// Position does not capture AceyDeucey's ToEnter.
+//
+// Positions make good map keys. The zero value is an empty board.
+type Position struct {
+	words [2]uint64 // points [1, 12] and White's bar, points [13, 24] and Red's bar
+}
+
+const (
+	positionRedFlag = 16
+	positionBarBit  = 60
+)
+
+func (b *Board) Position() Position {
+	p := Position{}
+	for i := 1; i < 25; i++ {
+		p.setPoint(i, b.Pips[i])
+	}
+	p.setBar(White, b.Pips[BarWhitePip].NumCheckers())
+	p.setBar(Red, b.Pips[BarRedPip].NumCheckers())
+	return p
+}
+
+// i must be in [1, 24]
+func (p Position) Point(i int) Point {
+	k := i - 1
+	f := (p.words[k/12] >> (uint(k%12) * 5)) & 31
+	if f&positionRedFlag != 0 {
+		return Point(f &^ positionRedFlag)
+	}
+	return Point(-int8(f))
+}
+
+func (p *Position) setPoint(i int, pt Point) {
+	k := i - 1
+	f := uint64(pt.NumCheckers())
+	if pt > 0 {
+		f |= positionRedFlag
+	}
+	shift := uint(k%12) * 5
+	p.words[k/12] = p.words[k/12]&^(31<<shift) | f<<shift
+}
+
+func (p Position) Bar(player Checker) int {
+	return int(p.words[player.positionWord()] >> positionBarBit)
+}
+
+func (p *Position) setBar(player Checker, n int) {
+	w := player.positionWord()
+	p.words[w] = p.words[w]&^(15<<positionBarBit) | uint64(n)<<positionBarBit
+}
+
+func (c Checker) positionWord() int {
+	if c == Red {
+		return 1
+	}
+	return 0
+}
+
+// The number of player's checkers on the board or the bar.
+func (p Position) NumInPlay(player Checker) (result int) {
+	for i := 1; i < 25; i++ {
+		result += p.Point(i).Num(player)
+	}
+	return result + p.Bar(player)
+}
+
+// The inverse of Board.Position(), assuming no checkers are waiting to enter.
+func (p Position) Pips() (pips Points28) {
+	for i := 1; i < 25; i++ {
+		pips[i] = p.Point(i)
+	}
+	pips[BarWhitePip].Reset(p.Bar(White), White)
+	pips[BarRedPip].Reset(p.Bar(Red), Red)
+	pips[BorneOffWhitePip].Reset(15-p.NumInPlay(White), White)
+	pips[BorneOffRedPip].Reset(15-p.NumInPlay(Red), Red)
+	return
+}
+
+// A node in the move generator's search: a Position and what's left of the
+// Roll along with the Board.Hash() of the two, which moves update
+// incrementally.
+type positionNode struct {
+	Position Position
+	Roll     Roll // unused
+	RollUsed Roll // used
+	Hash     uint64
+}
+
+func (n *positionNode) setPoint(i int, pt Point) {
+	n.Hash ^= zobrist.points[i][n.Position.Point(i)+15] ^ zobrist.points[i][pt+15]
+	n.Position.setPoint(i, pt)
+}
+
+func (n *positionNode) setBar(player Checker, count int) {
+	n.Hash ^= zobrist.bars[player][n.Position.Bar(player)] ^ zobrist.bars[player][count]
+	n.Position.setBar(player, count)
+}
+
+func (n *positionNode) use(die Die) {
+	n.Hash ^= rollHashDelta(n.Roll, die)
+	n.Roll = n.Roll.Use(die, &n.RollUsed)
+}
+
+// The move generator's depth-first search over positionNodes. Two ways of
+// playing the dice often reach the same node, e.g. <6 5> then <5 6>, and
+// the second time around we skip the whole subtree because its leaves are
+// already among the results. This keeps the results in the order that
+// Board.boardQuasiLegalContinuations() would give.
+type nodeSearch struct {
+	roller     Checker
+	visited    map[uint64]nodeKey
+	collisions []nodeKey // the rare visited nodes whose hashes were taken
+	leaves     []positionNode
+}
+
+type nodeKey struct {
+	Position Position
+	Roll     Roll
+}
+
+// Equal nodes have equal Roll arrays, not just equal multisets, because
+// Roll.Use() always removes the first matching die.
+func (n *positionNode) key() nodeKey {
+	return nodeKey{n.Position, n.Roll}
+}
+
+// Marks n visited, returning false if it already was.
+func (s *nodeSearch) visit(n *positionNode) bool {
+	k := n.key()
+	prior, ok := s.visited[n.Hash]
+	if !ok {
+		s.visited[n.Hash] = k
+		return true
+	}
+	if prior == k {
+		return false
+	}
+	for _, c := range s.collisions {
+		if c == k {
+			return false
+		}
+	}
+	s.collisions = append(s.collisions, k)
+	return true
+}
+
+// Like quasiLegalContinuations() but generating Positions for the Standard
+// Variant, materializing Boards only at the end. The order of the results is
+// the very same.
+func (b *Board) positionQuasiLegalContinuations() []*Board {
+	s := nodeSearch{visited: make(map[uint64]nodeKey, 64)}
+	s.start(b)
+	results := make([]*Board, len(s.leaves))
+	for i, n := range s.leaves {
+		next := boardPool.Get().(*Board)
+		*next = *b
+		next.Pips = n.Position.Pips()
+		next.Roll = n.Roll
+		next.RollUsed = n.RollUsed
+		results[i] = next
+	}
+	return results
+}
+
+// Searches from b, reusing the memory of any previous search.
+func (s *nodeSearch) start(b *Board) {
+	s.roller = b.Roller
+	for h := range s.visited {
+		delete(s.visited, h)
+	}
+	s.collisions = s.collisions[:0]
+	s.leaves = s.leaves[:0]
+	s.search(positionNode{b.Position(), b.Roll, b.RollUsed, b.Hash()})
+}
+
+// Checkers on the bar must enter before anything else moves, and whatever we
+// reach when no more moves are possible is a leaf.
+func (s *nodeSearch) search(n positionNode) {
+	if !s.visit(&n) {
+		return
+	}
+	var moved bool
+	if n.Position.Bar(s.roller) > 0 {
+		moved = s.searchOffTheBar(&n)
+	} else {
+		moved = s.searchPostBar(&n)
+	}
+	if !moved {
+		s.leaves = append(s.leaves, n)
+	}
+}
+
+// Like Board.continuationsOffTheBar()
+func (s *nodeSearch) searchOffTheBar(n *positionNode) (moved bool) {
+	roller := s.roller
+	// Like Roll.UniqueDice() but without allocating.
+	for die := Die(6); die > ZeroDie; die-- {
+		if !n.Roll.contains(die) {
+			continue
+		}
+		i := int(die)
+		if roller == Red {
+			i = 25 - int(die)
+		}
+		if n.Position.Point(i).MadeBy(roller.OtherColor()) {
+			continue
+		}
+		next := *n
+		next.setBar(roller, n.Position.Bar(roller)-1)
+		next.land(roller, i)
+		next.use(die)
+		s.search(next)
+		moved = true
+	}
+	return
+}
+
+// Places one of roller's checkers on point i, hitting a blot if there is one.
+func (n *positionNode) land(roller Checker, i int) {
+	pt := n.Position.Point(i)
+	if other := roller.OtherColor(); pt.Num(other) > 0 {
+		n.setBar(other, n.Position.Bar(other)+1)
+		pt = 0
+	}
+	pt.Add(roller)
+	n.setPoint(i, pt)
+}
+
+// Like Board.quasiLegalPostBarContinuations()
+func (s *nodeSearch) searchPostBar(n *positionNode) (moved bool) {
+	roller := s.roller
+	for _, die := range n.Roll {
+		if die == ZeroDie {
+			break
+		}
+		for i := 1; i < 25; i++ {
+			pt := n.Position.Point(i)
+			if pt.Num(roller) == 0 {
+				continue
+			}
+			targetPip, can := roller.canMoveCheckerInPosition(&n.Position, i, die)
+			if !can {
+				continue
+			}
+			next := *n
+			pt.Subtract()
+			next.setPoint(i, pt)
+			if targetPip != BorneOffWhitePip && targetPip != BorneOffRedPip {
+				next.land(roller, targetPip)
+			}
+			next.use(die)
+			s.search(next)
+			moved = true
+		}
+	}
+	return
+}
+
+// Like Board.canBearOff()
+func (roller Checker) canBearOffInPosition(p *Position) bool {
+	if p.Bar(roller) > 0 {
+		return false
+	}
+	lo, hi := 7, 24
+	if roller == White {
+		lo, hi = 1, 18
+	}
+	for i := lo; i <= hi; i++ {
+		if p.Point(i).Num(roller) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Like Board.canMoveChecker(). targetPip is undefined unless can is true
+func (roller Checker) canMoveCheckerInPosition(p *Position, start int, die Die) (targetPip int, can bool) {
+	if roller == White {
+		targetPip = start + int(die)
+		if start >= 19 && targetPip > 24 {
+			goodEnough := true
+			if targetPip != 25 {
+				for i := 19; i < start; i++ {
+					if p.Point(i).Num(roller) > 0 {
+						goodEnough = false
+						break
+					}
+				}
+			}
+			if goodEnough {
+				can = roller.canBearOffInPosition(p)
+				targetPip = BorneOffWhitePip
+			}
+			return
+		}
+		can = !p.Point(targetPip).MadeBy(Red)
+		return
+	}
+	targetPip = start - int(die)
+	if start <= 6 && targetPip < 1 {
+		goodEnough := true
+		if targetPip != 0 {
+			for i := 6; i > start; i-- {
+				if p.Point(i).Num(roller) > 0 {
+					goodEnough = false
+					break
+				}
+			}
+		}
+		if goodEnough {
+			can = roller.canBearOffInPosition(p)
+			targetPip = BorneOffRedPip
+		}
+		return
+	}
+	can = !p.Point(targetPip).MadeBy(White)
+	return
+}
@@ -135,6 +135,25 @@ func (r Roll) Use(die Die, recipient *Roll) Roll {
 	return result
 }
 
+// Like len(r.Dice()) but without allocating.
+func (r *Roll) numDice() (n int) {
+	for _, d := range r {
+		if d != ZeroDie {
+			n++
+		}
+	}
+	return
+}
+
+func (r *Roll) contains(die Die) bool {
+	for _, d := range r {
+		if d == die {
+			return true
+		}
+	}
+	return false
+}
+
 // Returns the unique dice.
 //
 // Roll{6, 6, 6, 6}.UniqueDice() => []Die{6}
@@ -170,3 +189,36 @@ func (r Roll) Dice() (result []Die) {
 	}
 	return
 }
+
+// One of the 21 distinct rolls and how many of the 36 equally likely ways two
+// dice can land it covers: one for a doublet, two otherwise.
+type WeightedRoll struct {
+	Roll   Roll
+	Weight int
+}
+
+func (w WeightedRoll) Probability() float64 {
+	return float64(w.Weight) / 36
+}
+
+var allRolls [21]WeightedRoll
+
+func init() {
+	i := 0
+	for d0 := Die(6); d0 > ZeroDie; d0-- {
+		for d1 := d0; d1 > ZeroDie; d1-- {
+			if d0 == d1 {
+				allRolls[i] = WeightedRoll{Roll{d0, d0, d0, d0}, 1}
+			} else {
+				allRolls[i] = WeightedRoll{Roll{d0, d1}, 2}
+			}
+			i++
+		}
+	}
+}
+
+// Returns the 21 distinct rolls, <6 6 6 6> first and <1 1 1 1> last, for
+// computing expectations over the dice. The weights sum to 36.
+func AllRolls() [21]WeightedRoll {
+	return allRolls
+}
@@ -0,0 +1,161 @@
+package brd
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// How a game decides who moves first and with which Roll.
+type OpeningRoll uint8
+
+const (
+	// A random player rolls both dice, rolling again until it isn't a doublet.
+	RerollDoublets OpeningRoll = iota
+	// Each player rolls one die, rolling again on a tie, and whoever rolled
+	// higher plays both dice.
+	OneDieEach
+)
+
+func (o OpeningRoll) String() string {
+	switch o {
+	case RerollDoublets:
+		return "RerollDoublets"
+	case OneDieEach:
+		return "OneDieEach"
+	default:
+		return fmt.Sprintf("OpeningRoll(%d)", int(o))
+	}
+}
+
+// House rules. The zero value is the standard game: the Crawford rule,
+// doublets rerolled on the opening roll without automatic doubles, no limit on
+// the doubling cube, and gammons and backgammons both count.
+//
+// Opening and AutomaticDoubles do not apply to LongNardy, which has no cube.
+type Rules struct {
+	NoCrawfordRule bool
+	Opening        OpeningRoll
+	// Each tie on the opening roll doubles the Stakes, at most this many
+	// times, which must not exceed MaxAutomaticDoubles. Zero means no
+	// automatic doubles.
+	AutomaticDoubles uint8
+	NoGammons        bool   // every victory is worth the Stakes alone
+	NoBackgammons    bool   // a backgammon is worth what a gammon is
+	MaxCube          uint16 // zero means no limit. Otherwise the Stakes never exceed this.
+}
+
+// The most AutomaticDoubles there can be without the Stakes overflowing an int
+// on any platform.
+const MaxAutomaticDoubles = 30
+
+func (r Rules) String() string {
+	crawford := "on"
+	if r.NoCrawfordRule {
+		crawford = "off"
+	}
+	gammons := "on"
+	if r.NoGammons {
+		gammons = "off"
+	} else if r.NoBackgammons {
+		gammons = "nobg"
+	}
+	return fmt.Sprintf(
+		"Rules{Crawford %s,%v,AutoDbl:%d,MaxCube:%d,Gammons %s}",
+		crawford, r.Opening, r.AutomaticDoubles, r.MaxCube, gammons)
+}
+
+func (r Rules) invalidity(stakes int) string {
+	if r.Opening > OneDieEach {
+		return fmt.Sprintf("bad opening roll %v", r.Opening)
+	}
+	if r.AutomaticDoubles > MaxAutomaticDoubles {
+		return fmt.Sprintf("AutomaticDoubles %d exceed %d", r.AutomaticDoubles, MaxAutomaticDoubles)
+	}
+	if r.MaxCube != 0 && stakes > int(r.MaxCube) {
+		return fmt.Sprintf("Stakes %d exceed MaxCube %d", stakes, r.MaxCube)
+	}
+	return ""
+}
+
+// Like NewVariant() but with house rules.
+func NewGame(variant Variant, rules Rules, paranoid bool) *Board {
+	var board *Board
+	switch variant {
+	case Standard:
+		board = newStandard()
+	case LongNardy:
+		board = newLongNardy()
+	case AceyDeucey:
+		board = newAceyDeucey()
+	default:
+		panic(fmt.Sprintf("bad variant %v", variant))
+	}
+	board.SetRules(rules)
+	board.rollOpening()
+	if paranoid {
+		if v := board.Invalidity(EnforceRollValidity); v != "" {
+			panic(v)
+		}
+	}
+	return board
+}
+
+// Replaces the Rules.
+//
+// Invariant: rules.AutomaticDoubles does not exceed MaxAutomaticDoubles.
+func (b *Board) SetRules(rules Rules) {
+	if rules.AutomaticDoubles > MaxAutomaticDoubles {
+		panic(fmt.Sprintf("AutomaticDoubles %d exceed %d", rules.AutomaticDoubles, MaxAutomaticDoubles))
+	}
+	b.Rules = rules
+}
+
+// Chooses the Roller and rolls the first Roll of the game.
+func (b *Board) rollOpening() {
+	if b.Variant == LongNardy {
+		// Whoever wins the opening roll rolls again, so doublets are fine.
+		b.Roller = players[rand.Intn(2)]
+		b.Roll.New(&b.RollUsed)
+		return
+	}
+	ties := 0
+	if b.Rules.Opening == OneDieEach {
+		b.RollUsed = Roll{}
+		for {
+			white, red := Die(rand.Intn(6)+1), Die(rand.Intn(6)+1)
+			if white != red {
+				b.Roller = White
+				b.Roll = Roll{white, red}
+				if red > white {
+					b.Roller = Red
+					b.Roll = Roll{red, white}
+				}
+				return
+			}
+			ties++
+			b.automaticDouble(ties)
+		}
+	}
+	b.Roller = players[rand.Intn(2)]
+	for {
+		b.Roll.New(&b.RollUsed)
+		if b.Roll[0] != b.Roll[1] {
+			return
+		}
+		ties++
+		b.automaticDouble(ties)
+	}
+}
+
+// Doubles the Stakes after the given tie, counting from one, on the opening
+// roll if the Rules allow it.
+func (b *Board) automaticDouble(ties int) {
+	if ties <= int(b.Rules.AutomaticDoubles) && b.cubeBelowMax() {
+		b.Stakes *= 2
+	}
+}
+
+// Whether the Rules let the Stakes double again.
+func (b *Board) cubeBelowMax() bool {
+	return b.Rules.MaxCube == 0 || 2*b.Stakes <= int(b.Rules.MaxCube)
+}
@@ -4,12 +4,11 @@ import (
 	"fmt"
 )
 
-// A match to 5 using the Crawford rule would be Score{Goal: 5}. The Crawford
-// rule is a standard so our zero value uses it.
+// A match to 5 would be Score{Goal: 5}. Whether the Crawford rule applies is
+// up to the Board's Rules.
 type Score struct {
 	WhiteScore                int
 	RedScore                  int
-	NoCrawfordRule            bool
 	AlreadyPlayedCrawfordGame bool // TODO(chandler37): a test case where the crawford game is the last game, and another where the crawford game is not the last.
 	Goal                      int  // zero means we are not playing a match, just looking to maximize points.
 }
@@ -21,9 +20,6 @@ func (s *Score) Equals(o Score) bool {
 	if s.RedScore != o.RedScore {
 		return false
 	}
-	if s.NoCrawfordRule != o.NoCrawfordRule {
-		return false
-	}
 	if s.AlreadyPlayedCrawfordGame != o.AlreadyPlayedCrawfordGame {
 		return false
 	}
@@ -34,15 +30,9 @@ func (s *Score) Equals(o Score) bool {
 }
 
 func (s Score) String() string {
-	craw := ""
-	if s.NoCrawfordRule {
-		craw = fmt.Sprintf("off")
-	} else {
-		x := "inactive"
-		if s.AlreadyPlayedCrawfordGame {
-			x = "dormant"
-		}
-		craw = fmt.Sprintf("on,%s", x)
+	craw := "inactive"
+	if s.AlreadyPlayedCrawfordGame {
+		craw = "dormant"
 	}
 	return fmt.Sprintf(
 		"Score{Goal:%d,%v:%d,%v:%d,Crawford %s}",
@@ -60,8 +50,9 @@ func (s *Score) Update(victor Checker, stakes int) {
 	}
 }
 
+// Assumes that the Rules use the Crawford rule.
 func (s *Score) CrawfordRuleAppliesNextGame() bool {
-	if s.NoCrawfordRule || s.AlreadyPlayedCrawfordGame {
+	if s.AlreadyPlayedCrawfordGame {
 		return false
 	}
 	return s.RedScore+1 == s.Goal || s.WhiteScore+1 == s.Goal
@@ -0,0 +1,188 @@
+package brd
+
+// The shots at one blot: how many of the 36 rolls let the opponent hit it.
+type BlotShots struct {
+	Point    int // in [1, 24]
+	Direct   int // rolls that hit with a single die
+	Indirect int // rolls that hit only by combining dice
+}
+
+func (s BlotShots) Total() int {
+	return s.Direct + s.Indirect
+}
+
+// Returns the shots at each of player's blots in order of Point, assuming that
+// player's opponent rolls next, and the number of rolls that hit at least one
+// blot.
+//
+// Counts honor the opponent's obligation to enter from the bar first, points
+// made by player, and doublets. They ignore the rule that you must play as
+// much of the roll as possible, which once in a blue moon forbids a hit.
+//
+// Nobody hits in LongNardy.
+func (b *Board) Shots(player Checker) (shots []BlotShots, total int) {
+	if b.Variant == LongNardy {
+		return
+	}
+	var blots uint32
+	for i := 1; i < 25; i++ {
+		if b.Pips[i].Num(player) == 1 {
+			blots |= 1 << uint(i)
+		}
+	}
+	if blots == 0 {
+		return
+	}
+	s := shooter{board: b, player: player.OtherColor(), blots: blots}
+	var direct, indirect [25]int
+	for _, wr := range allRolls {
+		d, ind := s.hits(wr.Roll)
+		ind &^= d
+		if d|ind == 0 {
+			continue
+		}
+		total += wr.Weight
+		for i := 1; i < 25; i++ {
+			if d&(1<<uint(i)) != 0 {
+				direct[i] += wr.Weight
+			} else if ind&(1<<uint(i)) != 0 {
+				indirect[i] += wr.Weight
+			}
+		}
+	}
+	for i := 1; i < 25; i++ {
+		if blots&(1<<uint(i)) != 0 {
+			shots = append(shots, BlotShots{i, direct[i], indirect[i]})
+		}
+	}
+	return
+}
+
+// Sums, over player's blots, the shots at each blot times the pips that
+// player would lose if it were hit. Zero means no blot can be hit.
+func (b *Board) ShotLiability(player Checker) (result int) {
+	shots, _ := b.Shots(player)
+	for _, s := range shots {
+		lost := s.Point
+		if player == Red {
+			lost = 25 - s.Point
+		}
+		result += s.Total() * lost
+	}
+	return
+}
+
+// The opponent aiming at blots, a bitmask of points.
+type shooter struct {
+	board  *Board
+	player Checker
+	blots  uint32
+}
+
+// Where a checker of the shooter at point from lands moving die pips, or -1 if
+// that is off the board or onto a point made by the blots' owner. from is 0 or
+// 25 for the shooter's bar.
+func (s *shooter) step(from int, die Die) int {
+	to := from + int(die)
+	if s.player == Red {
+		to = from - int(die)
+	}
+	if to < 1 || to > 24 || s.board.Pips[to].MadeBy(s.player.OtherColor()) {
+		return -1
+	}
+	return to
+}
+
+func (s *shooter) hit(point int) uint32 {
+	if point < 0 {
+		return 0
+	}
+	return s.blots & (1 << uint(point))
+}
+
+// Returns bitmasks of the blots that roll hits with a single die and those it
+// hits by combining dice.
+func (s *shooter) hits(roll Roll) (direct, indirect uint32) {
+	bar, onBar := BarWhitePip, 0
+	if s.player == Red {
+		bar, onBar = BarRedPip, 25
+	}
+	numOnBar := s.board.Pips[bar].NumCheckers()
+	// In AceyDeucey the checkers yet to enter may enter, but needn't, so once
+	// the bar is clear they are like any other checker.
+	mayEnter := s.board.ToEnter[s.player] > 0
+	isSource := func(i int) bool {
+		return (i == onBar && mayEnter) || (i >= 1 && i <= 24 && s.board.Pips[i].Num(s.player) > 0)
+	}
+	if roll[0] == roll[1] {
+		die := roll[0]
+		moves := 4
+		var entry int
+		if numOnBar > 0 {
+			entry = s.step(onBar, die)
+			if entry < 0 {
+				return
+			}
+			direct |= s.hit(entry)
+			moves -= numOnBar
+		}
+		for from := 0; from < 26; from++ {
+			diceUsed := 0
+			if !isSource(from) {
+				if numOnBar == 0 || from != entry {
+					continue
+				}
+				diceUsed = 1 // one of the checkers that just entered
+			}
+			to := from
+			for j := 1; j <= moves; j++ {
+				if to = s.step(to, die); to < 0 {
+					break
+				}
+				if diceUsed+j == 1 {
+					direct |= s.hit(to)
+				} else {
+					indirect |= s.hit(to)
+				}
+			}
+		}
+		return
+	}
+	orders := [2][2]Die{{roll[0], roll[1]}, {roll[1], roll[0]}}
+	if numOnBar > 1 {
+		for _, o := range orders {
+			direct |= s.hit(s.step(onBar, o[0]))
+		}
+		return
+	}
+	if numOnBar == 1 {
+		for _, o := range orders {
+			entry := s.step(onBar, o[0])
+			if entry < 0 {
+				continue
+			}
+			direct |= s.hit(entry)
+			indirect |= s.hit(s.step(entry, o[1])) // the checker that just entered
+			for from := 0; from < 26; from++ {
+				if isSource(from) {
+					direct |= s.hit(s.step(from, o[1]))
+				}
+			}
+		}
+		return
+	}
+	for from := 0; from < 26; from++ {
+		if !isSource(from) {
+			continue
+		}
+		for _, o := range orders {
+			mid := s.step(from, o[0])
+			if mid < 0 {
+				continue
+			}
+			direct |= s.hit(mid)
+			indirect |= s.hit(s.step(mid, o[1]))
+		}
+	}
+	return
+}
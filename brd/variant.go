@@ -0,0 +1,57 @@
+package brd
+
+import (
+	"fmt"
+)
+
+// A Variant is the ruleset governing how checkers move and how a game is
+// scored. The zero value is standard backgammon.
+type Variant uint8
+
+const (
+	Standard Variant = iota
+	// See https://en.wikipedia.org/wiki/Long_Nardy and the comment atop
+	// nardy.go.
+	LongNardy
+	// See https://en.wikipedia.org/wiki/Acey-deucey and the comment atop
+	// aceydeucey.go.
+	AceyDeucey
+)
+
+var variants = [...]Variant{Standard, LongNardy, AceyDeucey}
+
+func (v Variant) String() string {
+	switch v {
+	case Standard:
+		return "Standard"
+	case LongNardy:
+		return "LongNardy"
+	case AceyDeucey:
+		return "AceyDeucey"
+	default:
+		return fmt.Sprintf("Variant(%d)", int(v))
+	}
+}
+
+// The inverse of Variant.String().
+func ParseVariant(s string) (Variant, error) {
+	for _, v := range variants {
+		if v.String() == s {
+			return v, nil
+		}
+	}
+	return Standard, fmt.Errorf("unknown variant %q", s)
+}
+
+// Like New() but for any Variant. NewVariant(Standard, p) is New(p).
+func NewVariant(variant Variant, paranoid bool) *Board {
+	return NewGame(variant, Rules{}, paranoid)
+}
+
+// There is no doubling cube in Long Nardy.
+func newLongNardy() *Board {
+	board := Board{Stakes: 1, Variant: LongNardy, FirstTurns: 2}
+	board.Pips[nardyHead(White)].Reset(15, White)
+	board.Pips[nardyHead(Red)].Reset(15, Red)
+	return &board
+}
@@ -0,0 +1,112 @@
+package brd
+
+import (
+	"math/rand"
+)
+
+// Zobrist hashing: a Board's hash is the XOR of a random number for each of
+// its features, so a move updates the hash by XORing out the old features of
+// the points it touches and XORing in the new ones.
+var zobrist struct {
+	points  [25][31]uint64 // indexed by point and Point+15
+	bars    [3][16]uint64  // indexed by Checker and count
+	toEnter [3][16]uint64  // indexed by Checker and count
+	dice    [7][4]uint64   // indexed by Die and how many of that Die came before it in the Roll
+	roller  [3]uint64
+}
+
+func init() {
+	// A private source so that the hash is the same from run to run and so
+	// that we don't disturb anyone's rand.Seed().
+	r := rand.New(rand.NewSource(0x6a09e667f3bcc908))
+	for i := range zobrist.points {
+		for j := range zobrist.points[i] {
+			zobrist.points[i][j] = r.Uint64()
+		}
+		// An empty point contributes nothing so that the empty board hashes
+		// to zero.
+		zobrist.points[i][15] = 0
+	}
+	for c := range zobrist.bars {
+		for n := 1; n < 16; n++ {
+			zobrist.bars[c][n] = r.Uint64()
+			zobrist.toEnter[c][n] = r.Uint64()
+		}
+	}
+	for d := 1; d < 7; d++ {
+		for k := range zobrist.dice[d] {
+			zobrist.dice[d][k] = r.Uint64()
+		}
+	}
+	zobrist.roller[White] = r.Uint64()
+	zobrist.roller[Red] = r.Uint64()
+}
+
+// A 64-bit Zobrist hash of the checkers, the Roller, and the unused Roll,
+// suitable for keying evaluation caches and transposition tables. It ignores
+// RollUsed, Bonus, RollAgain, FirstTurns, the cube, the MatchScore, the
+// Variant, and the Rules, and it counts borne-off checkers only implicitly, so Boards that
+// differ in those may collide. Equal Boards always have equal hashes.
+func (b *Board) Hash() uint64 {
+	h := b.Position().hash() ^ zobrist.roller[b.Roller] ^ rollHash(b.Roll)
+	h ^= zobrist.toEnter[White][b.ToEnter[White]] ^ zobrist.toEnter[Red][b.ToEnter[Red]]
+	return h
+}
+
+// A Board in the move generator's search along with its Hash(), which moves
+// update incrementally as they do a positionNode's.
+type boardNode struct {
+	Board *Board
+	Hash  uint64
+}
+
+func boardsOf(nodes []boardNode) []*Board {
+	result := make([]*Board, len(nodes))
+	for i, n := range nodes {
+		result[i] = n.Board
+	}
+	return result
+}
+
+// What b.Pips[i] contributes to Hash(). Borne-off checkers contribute nothing.
+func (b *Board) pipHash(i int) uint64 {
+	switch i {
+	case BorneOffWhitePip, BorneOffRedPip:
+		return 0
+	case BarWhitePip:
+		return zobrist.bars[White][b.Pips[i].NumCheckers()]
+	case BarRedPip:
+		return zobrist.bars[Red][b.Pips[i].NumCheckers()]
+	}
+	return zobrist.points[i][b.Pips[i]+15]
+}
+
+func (p Position) hash() (h uint64) {
+	for i := 1; i < 25; i++ {
+		h ^= zobrist.points[i][p.Point(i)+15]
+	}
+	return h ^ zobrist.bars[White][p.Bar(White)] ^ zobrist.bars[Red][p.Bar(Red)]
+}
+
+func rollHash(r Roll) (h uint64) {
+	var seen [7]int
+	for _, d := range r {
+		if d != ZeroDie {
+			h ^= zobrist.dice[d][seen[d]]
+			seen[d]++
+		}
+	}
+	return
+}
+
+// The change in rollHash() when die is used. Roll.Use() removes the first
+// matching die, but the hash is the same whichever copy goes.
+func rollHashDelta(r Roll, die Die) uint64 {
+	n := 0
+	for _, d := range r {
+		if d == die {
+			n++
+		}
+	}
+	return zobrist.dice[die][n-1]
+}
@@ -0,0 +1,205 @@
+// Package dataset plays games of self-play and writes positions from them,
+// with their features and how the games ended, for training models outside
+// of Go. See Generate().
+package dataset
+
+import (
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+	"github.com/chandler37/gobackgammon/features"
+	bgjson "github.com/chandler37/gobackgammon/json"
+	"github.com/chandler37/gobackgammon/rollout"
+)
+
+type Settings struct {
+	Games     int
+	FirstGame int64 // Generate() plays games FirstGame through FirstGame+Games-1
+	// Each plays for its color. They must be safe for concurrent use.
+	White, Red brd.Chooser
+	Workers    int // zero means one per CPU
+	Seed       int64
+	// The chance that we record each position. Zero means one.
+	Sample float64
+	// If non-nil, we roll out each recorded position with these Settings,
+	// seeded from their Seed, the game, and the ply. The rollout runs on
+	// the game's worker, so its Workers should usually be 1.
+	Rollout *rollout.Settings
+}
+
+// A position from a game, from the point of view of its Roller, i.e., the
+// player who just moved.
+type Record struct {
+	Game     int64
+	Ply      int               // how many moves came before this one's
+	Board    string            // per json.Serialize()
+	Features []float32         // per features.Extract()
+	Outcomes analysis.Outcomes // how the game ended, each 0 or 1
+	Equity   float64           // what the game was worth, i.e., Outcomes.Equity()
+	Rollout  *rollout.Stats    // nil unless Settings.Rollout is
+}
+
+// Plays Settings.Games games of the Standard variant, in parallel, and writes
+// the sampled positions to w in order of game and ply. w is not flushed.
+//
+// Each game's dice and sampling are seeded from Settings.Seed and the game's
+// number, so the records don't depend on how many workers share the work or
+// on math/rand's global source. (Choosers that break ties with math/rand, as
+// those of package ai do, are another matter.)
+//
+// Positions where the game is over are never recorded. The cube is ignored.
+func Generate(s Settings, w Writer) error {
+	if s.Games < 0 || s.FirstGame < 0 || s.White == nil || s.Red == nil || s.Sample < 0 || s.Sample > 1 {
+		panic("bad Settings")
+	}
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	type game struct {
+		number  int64
+		records []Record
+		err     error
+	}
+	games := make(chan game, workers)
+	next := s.FirstGame - 1
+	var stop int32 // nonzero once the writer fails
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&stop) == 0 {
+				number := atomic.AddInt64(&next, 1)
+				if number >= s.FirstGame+int64(s.Games) {
+					return
+				}
+				records, err := play(&s, number)
+				games <- game{number, records, err}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(games)
+	}()
+	// The games finish out of order, so we hold them until it's their turn.
+	waiting := map[int64]game{}
+	turn := s.FirstGame
+	var err error
+	for g := range games {
+		if err != nil {
+			continue
+		}
+		waiting[g.number] = g
+		for g, ok := waiting[turn]; ok; g, ok = waiting[turn] {
+			delete(waiting, turn)
+			turn++
+			if err = g.err; err != nil {
+				break
+			}
+			for i := range g.records {
+				if err = w.Write(&g.records[i]); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			atomic.StoreInt32(&stop, 1)
+		}
+	}
+	return err
+}
+
+// Plays the given game and returns its sampled positions.
+func play(s *Settings, number int64) ([]Record, error) {
+	seed := s.Seed*1000003 + number
+	dice := rand.New(rand.NewSource(seed))
+	sampler := rand.New(rand.NewSource(-1 - seed))
+	b := *brd.New(false)
+	b.Roll, b.RollUsed = roll(dice), brd.Roll{}
+	for b.Roll[2] != brd.ZeroDie {
+		// The opening roll is no doublet.
+		b.Roll = roll(dice)
+	}
+	b.Roller = brd.White
+	if dice.Intn(2) == 1 {
+		b.Roller = brd.Red
+	}
+	candidates := b.LegalContinuations()
+	var records []Record
+	var rollers []brd.Checker // of each record's Board
+	for ply := 0; ; ply++ {
+		choice := candidates[0]
+		if len(candidates) > 1 {
+			if analyzed := s.chooser(choice.Roller)(candidates); len(analyzed) > 0 {
+				choice = analyzed[0].Board
+			}
+		}
+		b = *choice
+		brd.OptionallyReturnBoardsToPool(candidates, &b)
+		final, over := analysis.FinalOutcomes(&b)
+		if over {
+			for i := range records {
+				r := &records[i]
+				r.Outcomes = final
+				if rollers[i] != b.Roller {
+					r.Outcomes = final.Flip()
+				}
+				r.Equity = r.Outcomes.Equity(&b, rollers[i])
+			}
+			return records, nil
+		}
+		if s.Sample == 0 || sampler.Float64() < s.Sample {
+			r, err := record(s, &b, number, ply)
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, r)
+			rollers = append(rollers, b.Roller)
+		}
+		candidates = b.ContinuationsForRoll(roll(dice))
+	}
+}
+
+func (s *Settings) chooser(player brd.Checker) brd.Chooser {
+	if player == brd.White {
+		return s.White
+	}
+	return s.Red
+}
+
+// All but the Outcomes and Equity of b's Record.
+func record(s *Settings, b *brd.Board, game int64, ply int) (Record, error) {
+	serialized, err := bgjson.Serialize(b)
+	if err != nil {
+		return Record{}, err
+	}
+	r := Record{Game: game, Ply: ply, Board: serialized, Features: features.New(b)}
+	if s.Rollout != nil {
+		rs := *s.Rollout
+		rs.Seed = (rs.Seed*1000003+game)*1009 + int64(ply)
+		stats := rollout.Rollout(b, rs)
+		r.Rollout = &stats
+	}
+	return r, nil
+}
+
+func roll(r *rand.Rand) brd.Roll {
+	x := r.Intn(6 * 6)
+	high, low := brd.Die(x%6+1), brd.Die(x/6+1)
+	if high < low {
+		high, low = low, high
+	}
+	if high == low {
+		return brd.Roll{high, high, high, high}
+	}
+	return brd.Roll{high, low}
+}
@@ -0,0 +1,246 @@
+package dataset
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chandler37/gobackgammon/ai"
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+	"github.com/chandler37/gobackgammon/features"
+	bgjson "github.com/chandler37/gobackgammon/json"
+	"github.com/chandler37/gobackgammon/rollout"
+)
+
+// Collects Records.
+type records []Record
+
+func (r *records) Write(x *Record) error {
+	*r = append(*r, *x)
+	return nil
+}
+
+func (r *records) Flush() error {
+	return nil
+}
+
+func (r records) String() string {
+	var lines []string
+	for _, x := range r {
+		line := fmt.Sprintf("game %d ply %d %+v", x.Game, x.Ply, x.Equity)
+		if x.Rollout != nil {
+			line += fmt.Sprintf(" rollout %+.3f", x.Rollout.Equity())
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestGenerate(t *testing.T) {
+	// It breaks no ties with math/rand.
+	chooser := ai.ChooserFromEvaluator(ai.ConservativeEvaluator)
+	s := Settings{Games: 3, FirstGame: 5, White: chooser, Red: chooser, Workers: 1, Seed: 37, Sample: 0.1}
+	var one records
+	if err := Generate(s, &one); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"game 5 ply 2 1",
+		"game 5 ply 3 -1",
+		"game 5 ply 4 1",
+		"game 5 ply 5 -1",
+		"game 5 ply 37 -1",
+		"game 5 ply 56 1",
+		"game 6 ply 23 -1",
+		"game 6 ply 28 1",
+		"game 6 ply 32 1",
+		"game 6 ply 38 1",
+		"game 6 ply 49 -1",
+		"game 6 ply 52 1",
+		"game 7 ply 21 -1",
+		"game 7 ply 26 1",
+		"game 7 ply 36 1",
+		"game 7 ply 45 -1",
+		"game 7 ply 50 1",
+		"game 7 ply 56 1",
+		"game 7 ply 57 -1",
+		"game 7 ply 58 1",
+		"game 7 ply 61 -1",
+		"game 7 ply 63 -1",
+		"game 7 ply 64 1",
+		"game 7 ply 67 -1",
+		"game 7 ply 91 -1",
+	}
+	if x := one.String(); x != strings.Join(expected, "\n") {
+		t.Errorf("%v", x)
+	}
+	s.Workers = 3
+	var three records
+	if err := Generate(s, &three); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(one, three) {
+		t.Errorf("%v", three)
+	}
+	for _, r := range one {
+		b, err := bgjson.Deserialize(r.Board)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(r.Features, features.New(b)) {
+			t.Errorf("%v", r)
+		}
+	}
+	s.Games = 1
+	s.Rollout = &rollout.Settings{Trials: 4, White: chooser, Red: chooser, Workers: 1}
+	var rolledOut records
+	if err := Generate(s, &rolledOut); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{
+		"game 5 ply 2 1 rollout +1.250",
+		"game 5 ply 3 -1 rollout -0.750",
+		"game 5 ply 4 1 rollout +0.000",
+		"game 5 ply 5 -1 rollout -1.000",
+		"game 5 ply 37 -1 rollout -1.000",
+		"game 5 ply 56 1 rollout +1.000",
+	}
+	if x := rolledOut.String(); x != strings.Join(expected, "\n") {
+		t.Errorf("%v", x)
+	}
+}
+
+// A Record with a rollout and one without.
+func examples(t *testing.T) []Record {
+	b := brd.New(false)
+	b.Roller = brd.White
+	b.Roll = brd.Roll{}
+	serialized, err := bgjson.Serialize(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	win := analysis.Outcomes{Win: 1, WinGammon: 1}
+	var stats rollout.Stats
+	stats.Add(win, 2)
+	stats.Add(analysis.Outcomes{}, -1)
+	return []Record{
+		{Game: 37, Ply: 4, Board: serialized, Features: features.New(b), Outcomes: win, Equity: 2, Rollout: &stats},
+		{Game: 38, Board: serialized, Features: features.New(b), Outcomes: win.Flip(), Equity: -2},
+	}
+}
+
+func TestFormats(t *testing.T) {
+	written := map[string]string{}
+	for _, format := range Formats {
+		var buf bytes.Buffer
+		w, err := NewWriter(format, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range examples(t) {
+			if err := w.Write(&r); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		written[format] = buf.String()
+	}
+	// The lines end with the features past Tesauro's and then the labels.
+	suffixes := map[string][]string{
+		"csv": {
+			",rollout_lose_gammon,rollout_lose_backgammon",
+			",1,1,4,0,1,2,77,21,0,167,1,1,4,0,1,2,77,21,0,167,0,1,1,0,0,0,2,2,0.5,1.5,0.5,0.5,0,0,0",
+			",1,1,4,0,1,2,77,21,0,167,1,1,4,0,1,2,77,21,0,167,0,0,0,0,1,0,-2,,,,,,,,",
+			"",
+		},
+		"jsonl": {
+			`,1,1,4,0,1,2,77,21,0,167,1,1,4,0,1,2,77,21,0,167,0],"win":1,"win_gammon":1,"win_backgammon":0,"lose_gammon":0,"lose_backgammon":0,"equity":2,"rollout_trials":2,"rollout_equity":0.5,"rollout_stderr":1.5,"rollout_win":0.5,"rollout_win_gammon":0.5,"rollout_win_backgammon":0,"rollout_lose_gammon":0,"rollout_lose_backgammon":0}`,
+			`,1,1,4,0,1,2,77,21,0,167,1,1,4,0,1,2,77,21,0,167,0],"win":0,"win_gammon":0,"win_backgammon":0,"lose_gammon":1,"lose_backgammon":0,"equity":-2}`,
+			"",
+		},
+	}
+	for format, expected := range suffixes {
+		lines := strings.Split(written[format], "\n")
+		if len(lines) != len(expected) {
+			t.Errorf("%v: %d lines", format, len(lines))
+			continue
+		}
+		for i, line := range lines {
+			if !strings.HasSuffix(line, expected[i]) {
+				t.Errorf("%v line %d: %v", format, i, line)
+			}
+		}
+	}
+	if x := strings.Count(strings.Split(written["csv"], "\n")[0], ","); x != 3+features.NumFeatures+6+8-1 {
+		t.Errorf("%d commas", x)
+	}
+	if !strings.Contains(written["csv"], `37,4,"{""wd"":1,""rd"":1,""p"":""W"",""p1"":""W2"",`) {
+		t.Errorf("%v", written["csv"])
+	}
+	// Each Record has 8+4+4 bytes, the board, the features, 6 float32s, and
+	// the rollout.
+	board := len(examples(t)[0].Board)
+	if x := len(written["binary"]); x != 20+2*(16+board+4*features.NumFeatures+24+4)+8*7 {
+		t.Errorf("%d bytes", x)
+	}
+	if _, err := NewWriter("xml", nil); err == nil || err.Error() != `unknown format "xml"` {
+		t.Errorf("%v", err)
+	}
+}
+
+func TestBinaryReader(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter("binary", &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := examples(t)
+	for _, r := range expected {
+		if err := w.Write(&r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	br, err := NewBinaryReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []Record
+	for {
+		r, err := br.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, *r)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("%v", actual)
+	}
+	br, err = NewBinaryReader(bytes.NewReader(data[:len(data)-1]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	br.Read()
+	if _, err := br.Read(); err != io.ErrUnexpectedEOF {
+		t.Errorf("%v", err)
+	}
+	if _, err := NewBinaryReader(strings.NewReader("gbgnnw1\n\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")); err == nil || err.Error() != "not a binary dataset" {
+		t.Errorf("%v", err)
+	}
+	data[len(binaryMagic)]++
+	if _, err := NewBinaryReader(bytes.NewReader(data)); err == nil || err.Error() != "the features have changed" {
+		t.Errorf("%v", err)
+	}
+}
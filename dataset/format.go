@@ -0,0 +1,333 @@
+package dataset
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/features"
+	"github.com/chandler37/gobackgammon/rollout"
+)
+
+// Writes Records in one of the Formats.
+type Writer interface {
+	Write(*Record) error
+	Flush() error
+}
+
+// The names NewWriter() accepts:
+//
+// "csv" has a header row naming the columns: game, ply, board, each of
+// features.Names(), win, win_gammon, win_backgammon, lose_gammon,
+// lose_backgammon, equity, and then, empty without a rollout,
+// rollout_trials, rollout_equity, rollout_stderr, and the rollout's
+// probabilities rollout_win through rollout_lose_backgammon.
+//
+// "jsonl" is a JSON object per line with the same names as keys, except that
+// "features" is an array in the order of features.Names(), and the rollout's
+// keys are absent without one.
+//
+// "binary" is described with NewBinaryReader().
+var Formats = []string{"csv", "jsonl", "binary"}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case "csv":
+		return newCSVWriter(w)
+	case "jsonl":
+		return &jsonLinesWriter{w: bufio.NewWriter(w)}, nil
+	case "binary":
+		return newBinaryWriter(w)
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+var outcomeNames = []string{"win", "win_gammon", "win_backgammon", "lose_gammon", "lose_backgammon"}
+
+func outcomeValues(o analysis.Outcomes) []float64 {
+	return []float64{o.Win, o.WinGammon, o.WinBackgammon, o.LoseGammon, o.LoseBackgammon}
+}
+
+type csvWriter struct {
+	w   *csv.Writer
+	row []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	header := append([]string{"game", "ply", "board"}, features.Names()...)
+	header = append(header, outcomeNames...)
+	header = append(header, "equity", "rollout_trials", "rollout_equity", "rollout_stderr")
+	for _, name := range outcomeNames {
+		header = append(header, "rollout_"+name)
+	}
+	c := &csvWriter{w: csv.NewWriter(w), row: make([]string, len(header))}
+	return c, c.w.Write(header)
+}
+
+func (c *csvWriter) Write(r *Record) error {
+	if len(r.Features) != features.NumFeatures {
+		panic("wrong number of features")
+	}
+	row := append(c.row[:0], strconv.FormatInt(r.Game, 10), strconv.Itoa(r.Ply), r.Board)
+	for _, x := range r.Features {
+		row = append(row, strconv.FormatFloat(float64(x), 'g', -1, 32))
+	}
+	for _, x := range append(outcomeValues(r.Outcomes), r.Equity) {
+		row = append(row, strconv.FormatFloat(x, 'g', -1, 64))
+	}
+	if r.Rollout == nil {
+		for i := 0; i < 3+len(outcomeNames); i++ {
+			row = append(row, "")
+		}
+	} else {
+		row = append(row, strconv.Itoa(r.Rollout.Trials))
+		for _, x := range append([]float64{r.Rollout.Equity(), r.Rollout.StdErr()}, outcomeValues(r.Rollout.Outcomes())...) {
+			row = append(row, strconv.FormatFloat(x, 'g', -1, 64))
+		}
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+type jsonLinesWriter struct {
+	w *bufio.Writer
+}
+
+type jsonRecord struct {
+	Game           int64     `json:"game"`
+	Ply            int       `json:"ply"`
+	Board          string    `json:"board"`
+	Features       []float32 `json:"features"`
+	Win            float64   `json:"win"`
+	WinGammon      float64   `json:"win_gammon"`
+	WinBackgammon  float64   `json:"win_backgammon"`
+	LoseGammon     float64   `json:"lose_gammon"`
+	LoseBackgammon float64   `json:"lose_backgammon"`
+	Equity         float64   `json:"equity"`
+	*jsonRollout
+}
+
+type jsonRollout struct {
+	Trials         int     `json:"rollout_trials"`
+	Equity         float64 `json:"rollout_equity"`
+	StdErr         float64 `json:"rollout_stderr"`
+	Win            float64 `json:"rollout_win"`
+	WinGammon      float64 `json:"rollout_win_gammon"`
+	WinBackgammon  float64 `json:"rollout_win_backgammon"`
+	LoseGammon     float64 `json:"rollout_lose_gammon"`
+	LoseBackgammon float64 `json:"rollout_lose_backgammon"`
+}
+
+func (j *jsonLinesWriter) Write(r *Record) error {
+	jr := jsonRecord{
+		Game:           r.Game,
+		Ply:            r.Ply,
+		Board:          r.Board,
+		Features:       r.Features,
+		Win:            r.Outcomes.Win,
+		WinGammon:      r.Outcomes.WinGammon,
+		WinBackgammon:  r.Outcomes.WinBackgammon,
+		LoseGammon:     r.Outcomes.LoseGammon,
+		LoseBackgammon: r.Outcomes.LoseBackgammon,
+		Equity:         r.Equity,
+	}
+	if s := r.Rollout; s != nil {
+		o := s.Outcomes()
+		jr.jsonRollout = &jsonRollout{
+			Trials:         s.Trials,
+			Equity:         s.Equity(),
+			StdErr:         s.StdErr(),
+			Win:            o.Win,
+			WinGammon:      o.WinGammon,
+			WinBackgammon:  o.WinBackgammon,
+			LoseGammon:     o.LoseGammon,
+			LoseBackgammon: o.LoseBackgammon,
+		}
+	}
+	line, err := json.Marshal(&jr)
+	if err != nil {
+		return err
+	}
+	j.w.Write(line)
+	return j.w.WriteByte('\n')
+}
+
+func (j *jsonLinesWriter) Flush() error {
+	return j.w.Flush()
+}
+
+// The magic number of the binary format.
+const binaryMagic = "gbgds01\n"
+
+type binaryWriter struct {
+	w   *bufio.Writer
+	buf []byte
+}
+
+func newBinaryWriter(w io.Writer) (*binaryWriter, error) {
+	b := &binaryWriter{w: bufio.NewWriter(w)}
+	b.buf = append(b.buf, binaryMagic...)
+	b.buf = appendUint64(b.buf, features.Fingerprint())
+	b.buf = appendUint32(b.buf, features.NumFeatures)
+	_, err := b.w.Write(b.buf)
+	return b, err
+}
+
+func (b *binaryWriter) Write(r *Record) error {
+	if len(r.Features) != features.NumFeatures {
+		panic("wrong number of features")
+	}
+	buf := appendUint64(b.buf[:0], uint64(r.Game))
+	buf = appendUint32(buf, uint32(r.Ply))
+	buf = appendUint32(buf, uint32(len(r.Board)))
+	buf = append(buf, r.Board...)
+	for _, x := range r.Features {
+		buf = appendUint32(buf, math.Float32bits(x))
+	}
+	for _, x := range append(outcomeValues(r.Outcomes), r.Equity) {
+		buf = appendUint32(buf, math.Float32bits(float32(x)))
+	}
+	if r.Rollout == nil {
+		buf = appendUint32(buf, 0)
+	} else {
+		s := r.Rollout
+		buf = appendUint32(buf, uint32(s.Trials))
+		for _, x := range append([]float64{s.Sum, s.SumSquares}, outcomeValues(s.Totals)...) {
+			buf = appendUint64(buf, math.Float64bits(x))
+		}
+	}
+	b.buf = buf
+	_, err := b.w.Write(buf)
+	return err
+}
+
+func appendUint32(buf []byte, x uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], x)
+	return append(buf, b[:]...)
+}
+
+func appendUint64(buf []byte, x uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	return append(buf, b[:]...)
+}
+
+func (b *binaryWriter) Flush() error {
+	return b.w.Flush()
+}
+
+// The most bytes of a serialized Board that a BinaryReader accepts.
+const maxBoard = 1 << 16
+
+// Reads the "binary" format, which is, in little-endian order:
+//
+//	the 8-byte magic number "gbgds01\n"
+//	features.Fingerprint(), a uint64
+//	features.NumFeatures, a uint32
+//	then, for each Record:
+//	  Game, an int64
+//	  Ply, a uint32
+//	  the length of Board, a uint32, and then Board
+//	  Features, the Outcomes in the order of analysis.Outcomes, and Equity,
+//	  as IEEE 754 float32s
+//	  the Rollout's Trials, a uint32, zero if there's no Rollout
+//	  if there is one, its Sum, SumSquares, and Totals, as IEEE 754
+//	  float64s
+type BinaryReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// Reads the header from r, which must match this program's
+// features.Fingerprint().
+func NewBinaryReader(r io.Reader) (*BinaryReader, error) {
+	br := &BinaryReader{r: bufio.NewReader(r), buf: make([]byte, len(binaryMagic)+12)}
+	if _, err := io.ReadFull(br.r, br.buf); err != nil {
+		return nil, err
+	}
+	if string(br.buf[:len(binaryMagic)]) != binaryMagic {
+		return nil, errors.New("not a binary dataset")
+	}
+	le := binary.LittleEndian
+	if le.Uint64(br.buf[len(binaryMagic):]) != features.Fingerprint() || le.Uint32(br.buf[len(binaryMagic)+8:]) != features.NumFeatures {
+		return nil, errors.New("the features have changed")
+	}
+	return br, nil
+}
+
+// Returns the next Record or, if there are no more, io.EOF.
+func (br *BinaryReader) Read() (*Record, error) {
+	le := binary.LittleEndian
+	read := func(n int) ([]byte, error) {
+		if cap(br.buf) < n {
+			br.buf = make([]byte, n)
+		}
+		_, err := io.ReadFull(br.r, br.buf[:n])
+		return br.buf[:n], err
+	}
+	buf, err := read(16)
+	if err == io.EOF {
+		return nil, err
+	}
+	if err != nil {
+		return nil, unexpected(err)
+	}
+	r := &Record{Game: int64(le.Uint64(buf)), Ply: int(le.Uint32(buf[8:]))}
+	n := le.Uint32(buf[12:])
+	if n > maxBoard {
+		return nil, fmt.Errorf("a board of %d bytes", n)
+	}
+	if buf, err = read(int(n) + 4*(features.NumFeatures+len(outcomeNames)+2)); err != nil {
+		return nil, unexpected(err)
+	}
+	r.Board = string(buf[:n])
+	buf = buf[n:]
+	float := func() float32 {
+		x := math.Float32frombits(le.Uint32(buf))
+		buf = buf[4:]
+		return x
+	}
+	r.Features = make([]float32, features.NumFeatures)
+	for i := range r.Features {
+		r.Features[i] = float()
+	}
+	o := &r.Outcomes
+	for _, x := range []*float64{&o.Win, &o.WinGammon, &o.WinBackgammon, &o.LoseGammon, &o.LoseBackgammon, &r.Equity} {
+		*x = float64(float())
+	}
+	trials := le.Uint32(buf)
+	if trials == 0 {
+		return r, nil
+	}
+	if buf, err = read(8 * (2 + len(outcomeNames))); err != nil {
+		return nil, unexpected(err)
+	}
+	s := &rollout.Stats{Trials: int(trials)}
+	t := &s.Totals
+	for _, x := range []*float64{&s.Sum, &s.SumSquares, &t.Win, &t.WinGammon, &t.WinBackgammon, &t.LoseGammon, &t.LoseBackgammon} {
+		*x = math.Float64frombits(le.Uint64(buf))
+		buf = buf[8:]
+	}
+	r.Rollout = s
+	return r, nil
+}
+
+// A Record cut short is an error.
+func unexpected(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
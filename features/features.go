@@ -0,0 +1,222 @@
+// Package features turns a Board into a fixed-order vector of named numbers
+// for machine learning and analysis. The order is the schema: a model trained
+// on vectors of one Version and Fingerprint() can be fed vectors of the same
+// Version and Fingerprint() by any program.
+//
+// Every vector is from the point of view of the Board's Roller, i.e., the
+// player who just moved, whose opponent is on roll. "mine" means the Roller's
+// and "theirs" the opponent's. Points are numbered as in brd.PerspectiveView.
+// The geometry is that of the Standard variant.
+package features
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// Changes whenever the schema does.
+const Version = 1
+
+// First come Tesauro's raw inputs less the units that say whose turn it is.
+// For each point 1 through 24, four for my checkers on my point and then
+// four for theirs on their point of the same number. Of the four, the first
+// three are 1 if there are at least one, two, or three checkers, and the
+// fourth is half of the checkers beyond three. Then half of my checkers on
+// the bar (including, in AceyDeucey, those yet to enter), the same for them,
+// and a fifteenth of the checkers each side has borne off.
+const NumTesauro = 24*8 + 4
+
+// Then come these for me and then the same for them, in raw units.
+const (
+	Anchors      = iota // points made on the 19 through 24 points
+	HomePoints          // points made on the 1 through 6 points
+	Builders            // checkers on the 4 through 11 points beyond the two that hold a made point
+	Blots               // points with one checker
+	MaxPrime            // the longest run of made points
+	CheckersBack        // on the bar or the 19 through 24 points
+	Timing              // pips playable without moving checkers already home
+	Escapes             // of the 36 rolls, how many let the rearmost checker get past the other side's points made in front of it
+	Shots               // of the 36 rolls, how many let the other side hit at least one blot
+	Pips                // the pip count
+	numSide
+)
+
+// The last feature is my pip count minus theirs.
+const PipGap = NumTesauro + 2*numSide
+
+const NumFeatures = PipGap + 1
+
+var names = func() []string {
+	result := make([]string, 0, NumFeatures)
+	for i := 1; i < 25; i++ {
+		for _, side := range [2]string{"mine", "theirs"} {
+			for _, unit := range [4]string{"1", "2", "3", "more"} {
+				result = append(result, fmt.Sprintf("%s_point%d_%s", side, i, unit))
+			}
+		}
+	}
+	result = append(result, "mine_bar", "theirs_bar", "mine_off", "theirs_off")
+	for _, side := range [2]string{"mine", "theirs"} {
+		for _, name := range [numSide]string{"anchors", "home_points", "builders", "blots", "max_prime", "checkers_back", "timing", "escapes", "shots", "pips"} {
+			result = append(result, side+"_"+name)
+		}
+	}
+	return append(result, "pip_gap")
+}()
+
+// The name of each feature, in order.
+func Names() []string {
+	return append([]string(nil), names...)
+}
+
+// A hash of Version and the names, to store alongside models and data.
+func Fingerprint() uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d\n%s", Version, strings.Join(names, "\n"))
+	return h.Sum64()
+}
+
+// Returns b's features. See also Extract().
+func New(b *brd.Board) []float32 {
+	x := make([]float32, NumFeatures)
+	Extract(b, x)
+	return x
+}
+
+// Writes b's features into x, which has NumFeatures elements.
+func Extract(b *brd.Board, x []float32) {
+	v := b.Perspective(b.Roller)
+	tesauro(&v, x[:NumTesauro])
+	_, mineShots := b.Shots(b.Roller)
+	_, theirShots := b.Shots(b.Roller.OtherColor())
+	side(&v, mineShots, x[NumTesauro:NumTesauro+numSide])
+	o := v.Opponent()
+	side(&o, theirShots, x[NumTesauro+numSide:PipGap])
+	x[PipGap] = x[NumTesauro+Pips] - x[NumTesauro+numSide+Pips]
+}
+
+// Writes only the first NumTesauro features of b into x, which has at least
+// NumTesauro elements.
+func Tesauro(b *brd.Board, x []float32) {
+	v := b.Perspective(b.Roller)
+	tesauro(&v, x[:NumTesauro])
+}
+
+func tesauro(v *brd.PerspectiveView, x []float32) {
+	for i := 1; i < 25; i++ {
+		tesauroPoint(v.Mine[i], x[8*(i-1):])
+		tesauroPoint(v.Theirs[i], x[8*(i-1)+4:])
+	}
+	x[24*8] = float32(v.Mine[25]) / 2
+	x[24*8+1] = float32(v.Theirs[25]) / 2
+	x[24*8+2] = float32(v.Mine[0]) / 15
+	x[24*8+3] = float32(v.Theirs[0]) / 15
+}
+
+func tesauroPoint(n int, x []float32) {
+	x[0], x[1], x[2], x[3] = 0, 0, 0, 0
+	if n > 0 {
+		x[0] = 1
+	}
+	if n > 1 {
+		x[1] = 1
+	}
+	if n > 2 {
+		x[2] = 1
+	}
+	if n > 3 {
+		x[3] = float32(n-3) / 2
+	}
+}
+
+// Writes the per-side features of v.Me into x. shots is the total of
+// Board.Shots() for v.Me.
+func side(v *brd.PerspectiveView, shots int, x []float32) {
+	for i := range x {
+		x[i] = 0
+	}
+	prime := 0
+	for i := 1; i < 25; i++ {
+		n := v.Mine[i]
+		made := n > 1
+		switch {
+		case made && i < 7:
+			x[HomePoints]++
+		case made && i > 18:
+			x[Anchors]++
+		}
+		if i >= 4 && i <= 11 {
+			if made {
+				x[Builders] += float32(n - 2)
+			} else {
+				x[Builders] += float32(n)
+			}
+		}
+		if n == 1 {
+			x[Blots]++
+		}
+		if made {
+			prime++
+			if float32(prime) > x[MaxPrime] {
+				x[MaxPrime] = float32(prime)
+			}
+		} else {
+			prime = 0
+		}
+		if i > 18 {
+			x[CheckersBack] += float32(n)
+		}
+	}
+	x[CheckersBack] += float32(v.Mine[25])
+	for i := 7; i < 26; i++ {
+		x[Timing] += float32((i - 6) * v.Mine[i])
+	}
+	x[Escapes] = float32(escapes(v))
+	x[Shots] = float32(shots)
+	mine, _ := v.PipCounts()
+	x[Pips] = float32(mine)
+}
+
+// Of the 36 rolls, how many let v.Me's rearmost checker reach an open point
+// nearer home than every point the opponent has made among the six in front
+// of it, moving it alone by one die or by both (with an open point in
+// between). Doublets count only their first two dice. All 36 if none of the
+// six is made.
+func escapes(v *brd.PerspectiveView) int {
+	rearmost := 0
+	for i := 25; i > 0; i-- {
+		if v.Mine[i] > 0 {
+			rearmost = i
+			break
+		}
+	}
+	last := 0
+	for i := rearmost - 6; i < rearmost; i++ {
+		if i > 0 && v.Blocked(i) {
+			last = i
+			break
+		}
+	}
+	if last == 0 {
+		return 36
+	}
+	open := func(i int) bool {
+		return i <= 0 || i >= 25 || !v.Blocked(i)
+	}
+	escaped := func(i int) bool {
+		return i < last && open(i)
+	}
+	result := 0
+	for d1 := 1; d1 < 7; d1++ {
+		for d2 := 1; d2 < 7; d2++ {
+			a, b := rearmost-d1, rearmost-d2
+			if escaped(a) || escaped(b) || ((open(a) || open(b)) && escaped(rearmost-d1-d2)) {
+				result++
+			}
+		}
+	}
+	return result
+}
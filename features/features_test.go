@@ -0,0 +1,112 @@
+package features
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+func opening() *brd.Board {
+	b := brd.New(false)
+	b.Roller = brd.White
+	b.Roll = brd.Roll{}
+	return b
+}
+
+// The features past Tesauro's as name=value.
+func extras(x []float32) string {
+	var parts []string
+	for i := NumTesauro; i < NumFeatures; i++ {
+		parts = append(parts, fmt.Sprintf("%s=%v", names[i], x[i]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestSchema(t *testing.T) {
+	n := Names()
+	if len(n) != NumFeatures || NumFeatures != 217 {
+		t.Errorf("%d names, %d features", len(n), NumFeatures)
+	}
+	if x := strings.Join([]string{n[0], n[3], n[4], n[191], n[192], n[195], n[NumTesauro+Anchors], n[NumTesauro+numSide+Pips], n[PipGap]}, " "); x != "mine_point1_1 mine_point1_more theirs_point1_1 theirs_point24_more mine_bar theirs_off mine_anchors theirs_pips pip_gap" {
+		t.Errorf("%v", x)
+	}
+	n[0] = "changed"
+	if names[0] != "mine_point1_1" {
+		t.Errorf("Names() isn't a copy")
+	}
+	// If this changes, so must Version.
+	if x := Fingerprint(); x != 0x2f1b83cb3fda8f20 {
+		t.Errorf("%#x", x)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	b := opening()
+	x := New(b)
+	if s := extras(x); s != "mine_anchors=1 mine_home_points=1 mine_builders=4 mine_blots=0 mine_max_prime=1 mine_checkers_back=2 mine_timing=77 mine_escapes=21 mine_shots=0 mine_pips=167 theirs_anchors=1 theirs_home_points=1 theirs_builders=4 theirs_blots=0 theirs_max_prime=1 theirs_checkers_back=2 theirs_timing=77 theirs_escapes=21 theirs_shots=0 theirs_pips=167 pip_gap=0" {
+		t.Errorf("%v", s)
+	}
+	y := New(b.Mirror())
+	if !reflect.DeepEqual(x, y) {
+		t.Errorf("%v vs. %v", extras(x), extras(y))
+	}
+	tesauro := make([]float32, NumTesauro)
+	Tesauro(b, tesauro)
+	if !reflect.DeepEqual(tesauro, x[:NumTesauro]) {
+		t.Errorf("Tesauro() disagrees with Extract()")
+	}
+	// White has a checker on the bar, and Red has split its six point to make
+	// its seven.
+	b.Pips[1].Subtract()
+	b.Pips[brd.BarWhitePip].Add(brd.White)
+	b.Pips[6].Reset(1, brd.Red)
+	b.Pips[7].Reset(2, brd.Red)
+	Extract(b, x)
+	if s := extras(x); s != "mine_anchors=0 mine_home_points=1 mine_builders=4 mine_blots=1 mine_max_prime=1 mine_checkers_back=2 mine_timing=78 mine_escapes=36 mine_shots=31 mine_pips=168 theirs_anchors=1 theirs_home_points=0 theirs_builders=2 theirs_blots=1 theirs_max_prime=2 theirs_checkers_back=2 theirs_timing=79 theirs_escapes=21 theirs_shots=24 theirs_pips=157 pip_gap=11" {
+		t.Errorf("%v", s)
+	}
+	// Extract() overwrites everything.
+	if !reflect.DeepEqual(x, New(b)) {
+		t.Errorf("Extract() reused stale values")
+	}
+}
+
+func TestEscapes(t *testing.T) {
+	tests := []struct {
+		made     []int // White's points in front of Red's checker on Red's 24, in Red's numbers
+		expected int
+	}{
+		{nil, 36},
+		{[]int{12, 13}, 36},                // too far away to matter
+		{[]int{18}, 20},                    // any 7 or more but 6-6
+		{[]int{19, 20, 21, 22, 23}, 11},    // only a 6
+		{[]int{18, 19, 20, 21, 22, 23}, 0}, // a full prime
+		{[]int{18, 20, 22}, 15},            // 7 or more with an odd die
+	}
+	for _, test := range tests {
+		b := opening()
+		b.Roller = brd.Red
+		b.Pips = brd.Points28{}
+		b.Pips[24].Reset(1, brd.Red)
+		b.Pips[brd.BorneOffRedPip].Reset(14, brd.Red)
+		for _, p := range test.made {
+			b.Pips[p].Reset(2, brd.White)
+		}
+		b.Pips[brd.BorneOffWhitePip].Reset(15-2*len(test.made), brd.White)
+		v := b.Perspective(brd.Red)
+		if x := escapes(&v); x != test.expected {
+			t.Errorf("%v: %d", test.made, x)
+		}
+	}
+}
+
+func BenchmarkExtract(b *testing.B) {
+	board := opening()
+	x := make([]float32, NumFeatures)
+	for i := 0; i < b.N; i++ {
+		Extract(board, x)
+	}
+}
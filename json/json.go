@@ -16,6 +16,7 @@ import (
 func Serialize(b *brd.Board) (string, error) {
 	cb := &compactBoard{}
 	cb.serializeMatchScore(&b.MatchScore)
+	cb.serializeRules(&b.Rules)
 	if b.WhiteCanDouble {
 		cb.WhiteCanDouble = 1
 	}
@@ -26,6 +27,14 @@ func Serialize(b *brd.Board) (string, error) {
 	if b.Roller == brd.Red {
 		cb.Roller = "r"
 	}
+	cb.Variant = int(b.Variant)
+	cb.WhiteToEnter = int(b.ToEnter[brd.White])
+	cb.RedToEnter = int(b.ToEnter[brd.Red])
+	cb.Bonus = int(b.Bonus)
+	if b.RollAgain {
+		cb.RollAgain = 1
+	}
+	cb.FirstTurns = int(b.FirstTurns)
 	cb.RollUsed = makeCompactRoll(&b.RollUsed)
 	cb.Roll = makeCompactRoll(&b.Roll)
 	var err error
@@ -127,6 +136,21 @@ func Deserialize(s string) (*brd.Board, error) {
 	default:
 		return nil, fmt.Errorf("bad Roller in %v", s)
 	}
+	b.Variant = brd.Variant(cb.Variant)
+	if cb.WhiteToEnter < 0 || cb.WhiteToEnter > 15 || cb.RedToEnter < 0 || cb.RedToEnter > 15 {
+		return nil, fmt.Errorf("bad checkers to enter in %v", s)
+	}
+	b.ToEnter[brd.White] = uint8(cb.WhiteToEnter)
+	b.ToEnter[brd.Red] = uint8(cb.RedToEnter)
+	if cb.Bonus < 0 || cb.Bonus > 6 {
+		return nil, fmt.Errorf("bad Bonus in %v", s)
+	}
+	b.Bonus = brd.Die(cb.Bonus)
+	b.RollAgain = cb.RollAgain != 0
+	if cb.FirstTurns < 0 || cb.FirstTurns > 2 {
+		return nil, fmt.Errorf("bad FirstTurns in %v", s)
+	}
+	b.FirstTurns = uint8(cb.FirstTurns)
 	b.RollUsed, err = parseRoll(cb.RollUsed)
 	if err != nil {
 		return nil, fmt.Errorf("bad RollUsed in %v: %v", s, err)
@@ -147,6 +171,13 @@ func Deserialize(s string) (*brd.Board, error) {
 		b.RedCanDouble = false
 	}
 	cb.deserializeMatchScore(&b.MatchScore)
+	if err := cb.deserializeRules(&b.Rules); err != nil {
+		return nil, fmt.Errorf("bad rules in %v: %v", s, err)
+	}
+	if cb.MatchScore != nil && cb.MatchScore.NoCrawfordRule != 0 {
+		// Scores carried the Crawford rule before Rules did.
+		b.Rules.NoCrawfordRule = true
+	}
 	if iv := b.Invalidity(brd.IgnoreRollValidity); iv != "" {
 		return nil, fmt.Errorf("invalid board: %v", iv)
 	}
@@ -229,16 +260,32 @@ type compactBoard struct {
 	P26            string        `json:"p26,omitempty"`
 	P27            string        `json:"p27,omitempty"`
 	MatchScore     *compactScore `json:"s,omitempty"`
+	Variant        int           `json:"v,omitempty"`
+	WhiteToEnter   int           `json:"we,omitempty"`
+	RedToEnter     int           `json:"re,omitempty"`
+	Bonus          int           `json:"b,omitempty"`
+	RollAgain      int           `json:"ra,omitempty"`
+	FirstTurns     int           `json:"ft,omitempty"`
+	Rules          *compactRules `json:"h,omitempty"`
 }
 
 type compactScore struct {
 	Goal                      int `json:"g,omitempty"`
 	WhiteScore                int `json:"w,omitempty"`
 	RedScore                  int `json:"r,omitempty"`
-	NoCrawfordRule            int `json:"n,omitempty"`
+	NoCrawfordRule            int `json:"n,omitempty"` // only read, for old serializations; see compactRules
 	AlreadyPlayedCrawfordGame int `json:"a,omitempty"`
 }
 
+type compactRules struct {
+	NoCrawfordRule   int `json:"n,omitempty"`
+	Opening          int `json:"o,omitempty"`
+	AutomaticDoubles int `json:"ad,omitempty"`
+	NoGammons        int `json:"ng,omitempty"`
+	NoBackgammons    int `json:"nb,omitempty"`
+	MaxCube          int `json:"mc,omitempty"`
+}
+
 // "W15" for fifteen White or "r" for one Red or "" for an empty Point
 func makeCompactPoint(pt *brd.Point) string {
 	nr := pt.NumRed()
@@ -347,9 +394,6 @@ func (cb *compactBoard) serializeMatchScore(s *brd.Score) {
 	cs.Goal = s.Goal
 	cs.WhiteScore = s.WhiteScore
 	cs.RedScore = s.RedScore
-	if s.NoCrawfordRule {
-		cs.NoCrawfordRule = 1
-	}
 	if s.AlreadyPlayedCrawfordGame {
 		cs.AlreadyPlayedCrawfordGame = 1
 	}
@@ -423,10 +467,46 @@ func (cb *compactBoard) deserializeMatchScore(score *brd.Score) {
 	score.Goal = cb.MatchScore.Goal
 	score.WhiteScore = cb.MatchScore.WhiteScore
 	score.RedScore = cb.MatchScore.RedScore
-	if cb.MatchScore.NoCrawfordRule != 0 {
-		score.NoCrawfordRule = true
-	}
 	if cb.MatchScore.AlreadyPlayedCrawfordGame != 0 {
 		score.AlreadyPlayedCrawfordGame = true
 	}
 }
+
+func (cb *compactBoard) serializeRules(r *brd.Rules) {
+	cr := compactRules{}
+	if r.NoCrawfordRule {
+		cr.NoCrawfordRule = 1
+	}
+	cr.Opening = int(r.Opening)
+	cr.AutomaticDoubles = int(r.AutomaticDoubles)
+	if r.NoGammons {
+		cr.NoGammons = 1
+	}
+	if r.NoBackgammons {
+		cr.NoBackgammons = 1
+	}
+	cr.MaxCube = int(r.MaxCube)
+	zero := compactRules{}
+	if cr != zero {
+		cb.Rules = &cr
+	}
+}
+
+func (cb *compactBoard) deserializeRules(r *brd.Rules) error {
+	if cb.Rules == nil {
+		return nil
+	}
+	if cb.Rules.Opening < 0 || cb.Rules.Opening > 255 || cb.Rules.AutomaticDoubles < 0 || cb.Rules.AutomaticDoubles > 255 {
+		return fmt.Errorf("out of range")
+	}
+	if cb.Rules.MaxCube < 0 || cb.Rules.MaxCube > 65535 {
+		return fmt.Errorf("MaxCube out of range")
+	}
+	r.NoCrawfordRule = cb.Rules.NoCrawfordRule != 0
+	r.Opening = brd.OpeningRoll(cb.Rules.Opening)
+	r.AutomaticDoubles = uint8(cb.Rules.AutomaticDoubles)
+	r.NoGammons = cb.Rules.NoGammons != 0
+	r.NoBackgammons = cb.Rules.NoBackgammons != 0
+	r.MaxCube = uint16(cb.Rules.MaxCube)
+	return nil
+}
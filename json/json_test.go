@@ -61,9 +61,9 @@ func TestSer(t *testing.T) {
 				b.Pips[6].Subtract()
 				b.MatchScore.WhiteScore = 1
 				b.MatchScore.AlreadyPlayedCrawfordGame = true
-				b.MatchScore.NoCrawfordRule = true
+				b.Rules.NoCrawfordRule = true
 			},
-			`{"r":"41","p":"r","p1":"W2","p6":"r3","p8":"r3","p12":"W5","p13":"r5","p17":"W3","p19":"W5","p24":"r2","p25":"r","p27":"r","s":{"w":1,"n":1,"a":1}}`,
+			`{"r":"41","p":"r","p1":"W2","p6":"r3","p8":"r3","p12":"W5","p13":"r5","p17":"W3","p19":"W5","p24":"r2","p25":"r","p27":"r","s":{"w":1,"a":1},"h":{"n":1}}`,
 		},
 		example{
 			373737,
@@ -72,6 +72,32 @@ func TestSer(t *testing.T) {
 			},
 			`{"r":"41","st":2,"wd":1,"rd":1,"p":"r","p1":"W2","p6":"r5","p8":"r3","p12":"W5","p13":"r5","p17":"W3","p19":"W5","p24":"r2"}`,
 		},
+		example{
+			37,
+			func(b *brd.Board) {
+				*b = *brd.NewVariant(brd.LongNardy, true)
+			},
+			`{"r":"42","p":"W","p12":"W15","p24":"r15","v":1,"ft":2}`,
+		},
+		example{
+			37,
+			func(b *brd.Board) {
+				*b = *brd.NewVariant(brd.AceyDeucey, true)
+				b.ToEnter[brd.Red] = 14
+				b.Pips[20].Reset(1, brd.Red)
+				b.Roll = brd.Roll{}
+				b.RollUsed = brd.Roll{2, 1}
+				b.Bonus = 4
+			},
+			`{"ru":"21","wd":1,"rd":1,"p":"W","p20":"r","v":2,"we":15,"re":14,"b":4}`,
+		},
+		example{
+			37,
+			func(b *brd.Board) {
+				*b = *brd.NewGame(brd.Standard, brd.Rules{NoCrawfordRule: true, Opening: brd.OneDieEach, AutomaticDoubles: 2, NoBackgammons: true, MaxCube: 64}, true)
+			},
+			`{"r":"52","wd":1,"rd":1,"p":"W","p1":"W2","p6":"r5","p8":"r3","p12":"W5","p13":"r5","p17":"W3","p19":"W5","p24":"r2","h":{"n":1,"o":1,"ad":2,"nb":1,"mc":64}}`,
+		},
 	}
 	for _, ex := range examples {
 		rand.Seed(ex.Seed)
@@ -90,6 +116,20 @@ func TestSer(t *testing.T) {
 	}
 }
 
+// Scores carried the Crawford rule before Rules did.
+func TestDeserializeOldCrawfordRule(t *testing.T) {
+	b, err := Deserialize(`{"r":"41","p":"r","p1":"W2","p6":"r3","p8":"r3","p12":"W5","p13":"r5","p17":"W3","p19":"W5","p24":"r2","p25":"r","p27":"r","s":{"w":1,"n":1,"a":1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Rules.NoCrawfordRule || b.MatchScore != (brd.Score{WhiteScore: 1, AlreadyPlayedCrawfordGame: true}) {
+		t.Errorf("%v", b)
+	}
+	if s, err := Serialize(b); err != nil || s != `{"r":"41","p":"r","p1":"W2","p6":"r3","p8":"r3","p12":"W5","p13":"r5","p17":"W3","p19":"W5","p24":"r2","p25":"r","p27":"r","s":{"w":1,"a":1},"h":{"n":1}}` {
+		t.Errorf("%v %v", s, err)
+	}
+}
+
 func setUpTrickyBoardThatIsNotOurDictionary(b *brd.Board) {
 	b.MatchScore.Goal = 6
 	b.MatchScore.RedScore = 3
@@ -121,7 +161,7 @@ func TestCompression(t *testing.T) {
 			func(b *brd.Board) {
 				b.MatchScore.Goal = 5
 				b.MatchScore.RedScore = 4
-				b.MatchScore.NoCrawfordRule = true
+				b.Rules.NoCrawfordRule = true
 				b.Pips = brd.Points28{}
 				for i := 1; i < 14; i++ {
 					b.Pips[i].Reset(1, brd.White)
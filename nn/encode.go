@@ -0,0 +1,51 @@
+package nn
+
+import (
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+	"github.com/chandler37/gobackgammon/features"
+)
+
+// The inputs of every Net: the features.Tesauro() ones, from the point of
+// view of the Board's Roller, i.e., the player who just moved.
+const NumInputs = features.NumTesauro
+
+// Writes b's inputs into x, which has NumInputs elements.
+func encode(b *brd.Board, x []float32) {
+	features.Tesauro(b, x)
+}
+
+// Which Net judges a position.
+type Class uint8
+
+const (
+	Contact Class = iota
+	Race          // nobody can hit anybody
+	Crashed       // contact where a player has crashed
+	NumClasses
+)
+
+func (c Class) String() string {
+	switch c {
+	case Contact:
+		return "contact"
+	case Race:
+		return "race"
+	case Crashed:
+		return "crashed"
+	}
+	return "bad Class"
+}
+
+// A cheap, allocation-free coarsening of analysis.Classify(): its races and
+// bear-offs are Race, positions where analysis.IsCrashed() for either player
+// are Crashed, and the rest are Contact.
+func Classify(b *brd.Board) Class {
+	if b.Racing() {
+		return Race
+	}
+	if analysis.IsCrashed(b, brd.White) || analysis.IsCrashed(b, brd.Red) {
+		return Crashed
+	}
+	return Contact
+}
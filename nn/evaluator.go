@@ -0,0 +1,90 @@
+// Package nn judges backgammon positions with TD-Gammon-style neural networks
+// written in pure Go. An Evaluator holds one Net per Class of position and
+// satisfies ai.Evaluator and ai.BatchEvaluator.
+package nn
+
+import (
+	"math/rand"
+
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+type Evaluator struct {
+	Nets [NumClasses]*Net // indexed by Class
+}
+
+// An Evaluator with untrained Nets, each with the given number of hidden
+// units, randomized by seed.
+func New(hidden int, seed int64) *Evaluator {
+	r := rand.New(rand.NewSource(seed))
+	e := &Evaluator{}
+	for c := range e.Nets {
+		e.Nets[c] = NewNet(NumInputs, hidden, r)
+	}
+	return e
+}
+
+// Judges b for its Roller. Safe for concurrent use.
+func (e *Evaluator) Evaluate(b *brd.Board) analysis.Outcomes {
+	if o, over := analysis.FinalOutcomes(b); over {
+		return o
+	}
+	n := e.Nets[Classify(b)]
+	x := make([]float32, n.Inputs)
+	h := make([]float32, n.Hidden)
+	var y [NumOutputs]float32
+	encode(b, x)
+	n.forward(x, h, &y)
+	return outcomes(&y)
+}
+
+// Like calling Evaluate() for each board, only faster. Safe for concurrent
+// use.
+func (e *Evaluator) EvaluateBatch(boards []*brd.Board) []analysis.Outcomes {
+	result := make([]analysis.Outcomes, len(boards))
+	var byClass [NumClasses][]int // indices into boards
+	for i, b := range boards {
+		if o, over := analysis.FinalOutcomes(b); over {
+			result[i] = o
+			continue
+		}
+		c := Classify(b)
+		byClass[c] = append(byClass[c], i)
+	}
+	for c, indices := range byClass {
+		if len(indices) == 0 {
+			continue
+		}
+		n := e.Nets[c]
+		xs := make([]float32, len(indices)*n.Inputs)
+		hs := make([]float32, len(indices)*n.Hidden)
+		ys := make([][NumOutputs]float32, len(indices))
+		for k, i := range indices {
+			encode(boards[i], xs[k*n.Inputs:(k+1)*n.Inputs])
+		}
+		n.forwardBatch(xs, hs, ys)
+		for k, i := range indices {
+			result[i] = outcomes(&ys[k])
+		}
+	}
+	return result
+}
+
+// Converts a Net's outputs to consistent Outcomes: one can't win a gammon
+// more often than one wins, etc.
+func outcomes(y *[NumOutputs]float32) (o analysis.Outcomes) {
+	o.Win = float64(y[Win])
+	o.WinGammon = minFloat(float64(y[WinGammon]), o.Win)
+	o.WinBackgammon = minFloat(float64(y[WinBackgammon]), o.WinGammon)
+	o.LoseGammon = minFloat(float64(y[LoseGammon]), 1-o.Win)
+	o.LoseBackgammon = minFloat(float64(y[LoseBackgammon]), o.LoseGammon)
+	return
+}
+
+func minFloat(x, y float64) float64 {
+	if x < y {
+		return x
+	}
+	return y
+}
@@ -0,0 +1,89 @@
+package nn
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// The file format of an Evaluator's weights:
+//
+//	the 8-byte magic number "gbgnnw1\n"
+//	NumClasses, one byte
+//	then, for each Net in order of Class:
+//	  Inputs and Hidden, little-endian uint32s
+//	  InputWeights, HiddenBiases, OutputWeights, and OutputBiases, in the
+//	  order Net documents, as little-endian IEEE 754 float32s
+//
+// With 80 hidden units that comes to about 200 kilobytes.
+const weightsMagic = "gbgnnw1\n"
+
+// The most hidden units Read() accepts.
+const maxHidden = 1 << 16
+
+func (e *Evaluator) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(weightsMagic)
+	bw.WriteByte(byte(len(e.Nets)))
+	var buf [4]byte
+	put := func(x uint32) {
+		binary.LittleEndian.PutUint32(buf[:], x)
+		bw.Write(buf[:])
+	}
+	for _, n := range e.Nets {
+		put(uint32(n.Inputs))
+		put(uint32(n.Hidden))
+		for _, weights := range [][]float32{n.InputWeights, n.HiddenBiases, n.OutputWeights, n.OutputBiases[:]} {
+			for _, x := range weights {
+				put(math.Float32bits(x))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// The inverse of Write().
+func Read(r io.Reader) (*Evaluator, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(weightsMagic)+1)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+	if string(header[:len(weightsMagic)]) != weightsMagic {
+		return nil, errors.New("not neural network weights")
+	}
+	if Class(header[len(weightsMagic)]) != NumClasses {
+		return nil, fmt.Errorf("%d nets but we need %d", header[len(weightsMagic)], NumClasses)
+	}
+	var buf [8]byte
+	e := &Evaluator{}
+	for c := range e.Nets {
+		if _, err := io.ReadFull(br, buf[:]); err != nil {
+			return nil, fmt.Errorf("%v net: %v", Class(c), err)
+		}
+		inputs, hidden := binary.LittleEndian.Uint32(buf[:4]), binary.LittleEndian.Uint32(buf[4:])
+		if inputs != NumInputs || hidden < 1 || hidden > maxHidden {
+			return nil, fmt.Errorf("%v net: bad size: %d inputs and %d hidden units", Class(c), inputs, hidden)
+		}
+		n := &Net{
+			Inputs:        int(inputs),
+			Hidden:        int(hidden),
+			InputWeights:  make([]float32, inputs*hidden),
+			HiddenBiases:  make([]float32, hidden),
+			OutputWeights: make([]float32, NumOutputs*hidden),
+		}
+		for _, weights := range [][]float32{n.InputWeights, n.HiddenBiases, n.OutputWeights, n.OutputBiases[:]} {
+			for i := range weights {
+				if _, err := io.ReadFull(br, buf[:4]); err != nil {
+					return nil, fmt.Errorf("%v net: %v", Class(c), err)
+				}
+				weights[i] = math.Float32frombits(binary.LittleEndian.Uint32(buf[:4]))
+			}
+		}
+		e.Nets[c] = n
+	}
+	return e, nil
+}
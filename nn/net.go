@@ -0,0 +1,146 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+)
+
+// The outputs of every Net, each a probability for the Board's Roller as in
+// analysis.Outcomes.
+const (
+	Win = iota
+	WinGammon
+	WinBackgammon
+	LoseGammon
+	LoseBackgammon
+	NumOutputs
+)
+
+// A feed-forward network with one hidden layer of sigmoids and sigmoid
+// outputs.
+type Net struct {
+	Inputs, Hidden int
+	// Input by input, each input's weight for each hidden unit. This order
+	// lets us skip the many inputs that are zero.
+	InputWeights  []float32
+	HiddenBiases  []float32
+	OutputWeights []float32 // output by output, one weight per hidden unit
+	OutputBiases  [NumOutputs]float32
+}
+
+// A Net with small random weights.
+func NewNet(inputs, hidden int, r *rand.Rand) *Net {
+	n := &Net{
+		Inputs:        inputs,
+		Hidden:        hidden,
+		InputWeights:  make([]float32, inputs*hidden),
+		HiddenBiases:  make([]float32, hidden),
+		OutputWeights: make([]float32, NumOutputs*hidden),
+	}
+	for _, w := range [][]float32{n.InputWeights, n.HiddenBiases, n.OutputWeights} {
+		for i := range w {
+			w[i] = float32(r.Float64()*0.2 - 0.1)
+		}
+	}
+	return n
+}
+
+func sigmoid(x float32) float32 {
+	return float32(1 / (1 + math.Exp(-float64(x))))
+}
+
+// Computes the hidden layer h and the outputs y for the inputs x.
+func (n *Net) forward(x, h []float32, y *[NumOutputs]float32) {
+	copy(h, n.HiddenBiases)
+	for j, xj := range x {
+		if xj == 0 {
+			continue
+		}
+		row := n.InputWeights[j*n.Hidden : (j+1)*n.Hidden]
+		for k, w := range row {
+			h[k] += xj * w
+		}
+	}
+	for k := range h {
+		h[k] = sigmoid(h[k])
+	}
+	for o := range y {
+		sum := n.OutputBiases[o]
+		for k, w := range n.OutputWeights[o*n.Hidden : (o+1)*n.Hidden] {
+			sum += w * h[k]
+		}
+		y[o] = sigmoid(sum)
+	}
+}
+
+// forward() for many inputs at once. xs holds the inputs one after another
+// and hs the hidden layers likewise. It's faster when successive inputs are
+// alike, as the continuations of a position are, because we update the hidden
+// layer's sums for the inputs that changed rather than starting over.
+func (n *Net) forwardBatch(xs, hs []float32, ys [][NumOutputs]float32) {
+	sums := make([]float32, n.Hidden)
+	copy(sums, n.HiddenBiases)
+	previous := make([]float32, n.Inputs)
+	for b := range ys {
+		x := xs[b*n.Inputs : (b+1)*n.Inputs]
+		for j, xj := range x {
+			d := xj - previous[j]
+			if d == 0 {
+				continue
+			}
+			row := n.InputWeights[j*n.Hidden : (j+1)*n.Hidden]
+			for k, w := range row {
+				sums[k] += d * w
+			}
+		}
+		previous = x
+		h := hs[b*n.Hidden : (b+1)*n.Hidden]
+		for k, sum := range sums {
+			h[k] = sigmoid(sum)
+		}
+		for o := range ys[b] {
+			sum := n.OutputBiases[o]
+			for k, w := range n.OutputWeights[o*n.Hidden : (o+1)*n.Hidden] {
+				sum += w * h[k]
+			}
+			ys[b][o] = sigmoid(sum)
+		}
+	}
+}
+
+// Takes a step of gradient descent of size rate on the cross-entropy between
+// the outputs for the inputs x and target. h is scratch space for the hidden
+// layer.
+func (n *Net) train(x, h []float32, target *[NumOutputs]float32, rate float32) {
+	var y [NumOutputs]float32
+	n.forward(x, h, &y)
+	var dy [NumOutputs]float32 // d(cross-entropy)/d(the output's sum)
+	for o := range y {
+		dy[o] = y[o] - target[o]
+	}
+	for k, hk := range h {
+		g := float32(0)
+		for o := range dy {
+			g += dy[o] * n.OutputWeights[o*n.Hidden+k]
+		}
+		h[k] = g * hk * (1 - hk) // now d(cross-entropy)/d(the hidden unit's sum)
+		for o := range dy {
+			n.OutputWeights[o*n.Hidden+k] -= rate * dy[o] * hk
+		}
+	}
+	for o := range dy {
+		n.OutputBiases[o] -= rate * dy[o]
+	}
+	for j, xj := range x {
+		if xj == 0 {
+			continue
+		}
+		row := n.InputWeights[j*n.Hidden : (j+1)*n.Hidden]
+		for k, dh := range h {
+			row[k] -= rate * xj * dh
+		}
+	}
+	for k, dh := range h {
+		n.HiddenBiases[k] -= rate * dh
+	}
+}
@@ -0,0 +1,245 @@
+package nn
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chandler37/gobackgammon/ai"
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+func opening() *brd.Board {
+	b := brd.New(false)
+	b.Roller = brd.White
+	b.Roll = brd.Roll{}
+	return b
+}
+
+// The nonzero inputs as index:value.
+func nonzero(x []float32) string {
+	var parts []string
+	for i, v := range x {
+		if v != 0 {
+			parts = append(parts, fmt.Sprintf("%d:%v", i, v))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestEncode(t *testing.T) {
+	b := opening()
+	x := make([]float32, NumInputs)
+	encode(b, x)
+	// Each side has 2 on its 24 point, 5 on the 13, 3 on the 8, and 5 on the
+	// 6.
+	if s := nonzero(x); s != "40:1 41:1 42:1 43:1 44:1 45:1 46:1 47:1 56:1 57:1 58:1 60:1 61:1 62:1 96:1 97:1 98:1 99:1 100:1 101:1 102:1 103:1 184:1 185:1 188:1 189:1" {
+		t.Errorf("%v", s)
+	}
+	y := make([]float32, NumInputs)
+	encode(b.Mirror(), y)
+	if !reflect.DeepEqual(x, y) {
+		t.Errorf("%v vs. %v", nonzero(x), nonzero(y))
+	}
+	// White has a checker on the bar, and Red has only one checker on its six
+	// point but seven on its 24 and four borne off.
+	b.Pips[1].Subtract()
+	b.Pips[brd.BarWhitePip].Add(brd.White)
+	b.Pips[brd.BorneOffRedPip].Reset(4, brd.Red)
+	b.Pips[6].Reset(1, brd.Red)
+	b.Pips[24].Reset(7, brd.Red)
+	encode(b, x)
+	if s := nonzero(x); s != "40:1 41:1 42:1 43:1 44:1 56:1 57:1 58:1 60:1 61:1 62:1 96:1 97:1 98:1 99:1 100:1 101:1 102:1 103:1 184:1 188:1 189:1 190:1 191:2 192:0.5 195:0.26666668" {
+		t.Errorf("%v", s)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	b := opening()
+	if c := Classify(b); c != Contact {
+		t.Errorf("%v", c)
+	}
+	b.Pips = brd.Points28{}
+	b.Pips[3].Reset(2, brd.Red)
+	b.Pips[brd.BorneOffRedPip].Reset(13, brd.Red)
+	b.Pips[22].Reset(15, brd.White)
+	if c := Classify(b); c != Race {
+		t.Errorf("%v", c)
+	}
+	b.Pips[23].Reset(1, brd.Red)
+	b.Pips[brd.BorneOffRedPip].Reset(12, brd.Red)
+	if c := Classify(b); c != Contact {
+		t.Errorf("borne-off checkers aren't dead: %v", c)
+	}
+	b.Pips[22].Reset(7, brd.White)
+	b.Pips[24].Reset(8, brd.White)
+	if c := Classify(b); c != Crashed {
+		t.Errorf("%v", c)
+	}
+	if x := fmt.Sprint(Contact, Race, Crashed, NumClasses); x != "contact race crashed bad Class" {
+		t.Errorf("%v", x)
+	}
+}
+
+func TestEvaluator(t *testing.T) {
+	var _ ai.BatchEvaluator = (*Evaluator)(nil)
+	e := New(8, 37)
+	b := opening()
+	// Untrained, it knows nothing.
+	if x := e.Evaluate(b).Summary(); x != "win 52.4% (gammon 52.4% backgammon 45.7%) lose gammon 47.6% (backgammon 47.6%)" {
+		t.Errorf("%v", x)
+	}
+	b.Roll = brd.Roll{3, 1}
+	b.Roller = brd.Red
+	choices := b.LegalContinuations()
+	batch := e.EvaluateBatch(choices)
+	for i, c := range choices {
+		if o := e.Evaluate(c); !near(o, batch[i]) {
+			t.Errorf("%d: %v vs. %v", i, o.Summary(), batch[i].Summary())
+		}
+	}
+	var previous float64 = 9
+	for _, a := range ai.ChooserFromEvaluator(e)(choices) {
+		equity := a.Analysis.(ai.Evaluation).Equity
+		if equity > previous {
+			t.Errorf("%v", a)
+		}
+		previous = equity
+	}
+	// The game is over.
+	b = opening()
+	b.Pips = brd.Points28{}
+	b.Pips[brd.BorneOffWhitePip].Reset(15, brd.White)
+	b.Pips[2].Reset(15, brd.Red)
+	if x := e.Evaluate(b); x != (analysis.Outcomes{Win: 1, WinGammon: 1}) {
+		t.Errorf("%v", x.Summary())
+	}
+	if x := e.EvaluateBatch([]*brd.Board{b}); x[0] != (analysis.Outcomes{Win: 1, WinGammon: 1}) {
+		t.Errorf("%v", x[0].Summary())
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	e := New(5, 37)
+	var buf bytes.Buffer
+	if err := e.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if x := buf.Len(); x != 8+1+3*(8+4*(NumInputs*5+5+NumOutputs*5+NumOutputs)) {
+		t.Errorf("%d bytes", x)
+	}
+	data := buf.Bytes()
+	f, err := Read(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(e, f) {
+		t.Errorf("the Evaluator changed")
+	}
+	if _, err := Read(bytes.NewReader(data[:len(data)-1])); err == nil || err.Error() != "crashed net: unexpected EOF" {
+		t.Errorf("%v", err)
+	}
+	if _, err := Read(strings.NewReader("gbgbo1s\n\x03")); err == nil || err.Error() != "not neural network weights" {
+		t.Errorf("%v", err)
+	}
+}
+
+// Whether the Outcomes agree to within rounding.
+func near(x, y analysis.Outcomes) bool {
+	return math.Abs(x.Win-y.Win) < 1e-5 && math.Abs(x.WinGammon-y.WinGammon) < 1e-5 && math.Abs(x.WinBackgammon-y.WinBackgammon) < 1e-5 && math.Abs(x.LoseGammon-y.LoseGammon) < 1e-5 && math.Abs(x.LoseBackgammon-y.LoseBackgammon) < 1e-5
+}
+
+func BenchmarkEvaluate(b *testing.B) {
+	e := New(80, 37)
+	board := opening()
+	board.Roll = brd.Roll{6, 4}
+	choices := board.LegalContinuations()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, c := range choices {
+			e.Evaluate(c)
+		}
+	}
+}
+
+func BenchmarkClassify(b *testing.B) {
+	board := opening()
+	board.Roll = brd.Roll{6, 4}
+	choices := board.LegalContinuations()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, c := range choices {
+			Classify(c)
+		}
+	}
+}
+
+func BenchmarkEvaluateBatch(b *testing.B) {
+	e := New(80, 37)
+	board := opening()
+	board.Roll = brd.Roll{6, 4}
+	choices := board.LegalContinuations()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.EvaluateBatch(choices)
+	}
+}
+
+func TestTrain(t *testing.T) {
+	// For the benchmark's conservative player, which breaks ties with
+	// math/rand. Training doesn't use it.
+	rand.Seed(37)
+	dir := t.TempDir()
+	var summaries []string
+	settings := TrainSettings{
+		Games:           6,
+		Lambda:          0.7,
+		Rate:            0.1,
+		Seed:            37,
+		Dir:             dir,
+		CheckpointEvery: 3,
+		BenchmarkGames:  2,
+		Report: func(p Progress) {
+			summaries = append(summaries, strings.Replace(p.Summary(), dir+string(filepath.Separator), "", 1))
+		},
+	}
+	trained, err := Train(New(8, 37), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// It has a lot to learn.
+	expected := []string{
+		"after 3 games, weights-000000003.nn vs. the conservative player: equity -2.000 ± 0.000 in 2 trials: win 0.0% (gammon 0.0% backgammon 0.0%) lose gammon 100.0% (backgammon 0.0%)",
+		"after 6 games, weights-000000006.nn vs. the conservative player: equity -2.500 ± 0.500 in 2 trials: win 0.0% (gammon 0.0% backgammon 0.0%) lose gammon 100.0% (backgammon 50.0%)",
+	}
+	if x := strings.Join(summaries, "\n"); x != strings.Join(expected, "\n") {
+		t.Errorf("%v", x)
+	}
+	// We stop after three games and resume.
+	settings.Dir = t.TempDir()
+	settings.Games = 3
+	settings.Report = nil
+	if _, err := Train(New(8, 37), settings); err != nil {
+		t.Fatal(err)
+	}
+	settings.Games = 6
+	resumed, err := Train(New(8, 99), settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(trained, resumed) {
+		t.Errorf("resuming changed the weights")
+	}
+	if reflect.DeepEqual(trained, New(8, 37)) {
+		t.Errorf("training changed nothing")
+	}
+}
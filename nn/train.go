@@ -0,0 +1,269 @@
+package nn
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	"github.com/chandler37/gobackgammon/ai"
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+	"github.com/chandler37/gobackgammon/rollout"
+)
+
+// Settings for Train().
+type TrainSettings struct {
+	Games  int     // in total, counting those played before resuming
+	Lambda float64 // TD(λ)'s λ, e.g., 0.7
+	Rate   float64 // the learning rate, e.g., 0.1
+	Seed   int64
+	// Every CheckpointEvery games, and after the last, we write the weights
+	// to Dir and, if there's a Report to make, play BenchmarkGames games
+	// against ai.MakePlayerConservative(0, nil).
+	Dir             string
+	CheckpointEvery int
+	BenchmarkGames  int
+	Report          func(Progress) // optional; called after each checkpoint
+}
+
+// What Train() has achieved.
+type Progress struct {
+	Games     int    // played so far
+	Path      string // of the checkpoint
+	Benchmark rollout.Stats
+}
+
+func (p Progress) Summary() string {
+	return fmt.Sprintf("after %d games, %v vs. the conservative player: %v", p.Games, p.Path, p.Benchmark.Summary())
+}
+
+// Trains e by TD(λ) as it plays itself, starting from the latest checkpoint
+// in TrainSettings.Dir if there is one, and returns the trained Evaluator.
+//
+// Each game rolls its dice with a rand.Rand of its own, seeded with
+// TrainSettings.Seed and the game's number, and e breaks ties
+// deterministically, so training is reproducible, and resuming from a
+// checkpoint gives the same weights as never having stopped. Train never
+// seeds the global math/rand source. Progress.Benchmark is another matter: its
+// opponent, like every chooser of package ai but ChooserFromEvaluator(),
+// breaks ties with the global source, so it varies unless nothing else uses
+// math/rand meanwhile.
+//
+// We update the weights after each game toward the λ-returns computed with
+// the weights the game began with, i.e., offline TD(λ) in its forward view.
+func Train(e *Evaluator, s TrainSettings) (*Evaluator, error) {
+	if s.Lambda < 0 || s.Lambda > 1 || s.Rate <= 0 || s.CheckpointEvery < 0 || s.BenchmarkGames < 0 {
+		panic("bad TrainSettings")
+	}
+	games, latest, err := latestCheckpoint(s.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		e = latest
+	}
+	for games < s.Games {
+		e.learnFromGame(float32(s.Lambda), float32(s.Rate), rand.New(rand.NewSource(s.Seed*1000003+int64(games))))
+		games++
+		if games == s.Games || (s.CheckpointEvery > 0 && games%s.CheckpointEvery == 0) {
+			p := Progress{Games: games, Path: checkpointPath(s.Dir, games)}
+			if err := e.save(p.Path); err != nil {
+				return nil, err
+			}
+			if s.Report != nil {
+				p.Benchmark = benchmark(e, s.BenchmarkGames, s.Seed)
+				s.Report(p)
+			}
+		}
+	}
+	return e, nil
+}
+
+func checkpointPath(dir string, games int) string {
+	return filepath.Join(dir, fmt.Sprintf("weights-%09d.nn", games))
+}
+
+// Returns the latest checkpoint in dir and how many games preceded it, or
+// nil.
+func latestCheckpoint(dir string) (games int, e *Evaluator, err error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "weights-*.nn"))
+	if err != nil {
+		return 0, nil, err
+	}
+	path := ""
+	for _, p := range paths {
+		var g int
+		if _, err := fmt.Sscanf(filepath.Base(p), "weights-%d.nn", &g); err == nil && g > games {
+			games, path = g, p
+		}
+	}
+	if path == "" {
+		return 0, nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer f.Close()
+	if e, err = Read(f); err != nil {
+		return 0, nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return games, e, nil
+}
+
+// Writes e to path by way of a temporary file so that a crash leaves no
+// partial checkpoint.
+func (e *Evaluator) save(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := e.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Plays a game against itself with the given dice and learns from it.
+func (e *Evaluator) learnFromGame(lambda, rate float32, dice *rand.Rand) {
+	positions := playGame(ai.ChooserFromEvaluator(e), dice)
+	last := &positions[len(positions)-1]
+	final, over := analysis.FinalOutcomes(last)
+	if !over {
+		panic("the game isn't over")
+	}
+	// The λ-returns, from White's point of view, which doesn't change as the
+	// Roller does.
+	returns := make([]analysis.Outcomes, len(positions))
+	returns[len(returns)-1] = forWhite(final, last)
+	for t := len(positions) - 2; t >= 0; t-- {
+		next := &positions[t+1]
+		prediction := final
+		if t+1 < len(positions)-1 {
+			prediction = e.Evaluate(next)
+		}
+		returns[t] = mix(1-lambda, forWhite(prediction, next), lambda, returns[t+1])
+	}
+	x := make([]float32, NumInputs)
+	var h []float32
+	for t := range positions[:len(positions)-1] {
+		p := &positions[t]
+		n := e.Nets[Classify(p)]
+		if len(h) < n.Hidden {
+			h = make([]float32, n.Hidden)
+		}
+		target := targets(forWhite(returns[t], p)) // forWhite() is its own inverse
+		encode(p, x)
+		n.train(x, h[:n.Hidden], &target, rate)
+	}
+}
+
+// Plays a game of the Standard variant, ignoring the cube, with dice from
+// dice, and returns each position as its Roller left it. The game is over
+// in the last.
+func playGame(chooser brd.Chooser, dice *rand.Rand) []brd.Board {
+	b := *brd.New(false)
+	b.Roll, b.RollUsed = roll(dice), brd.Roll{}
+	for b.Roll[2] != brd.ZeroDie {
+		// The opening roll is no doublet.
+		b.Roll = roll(dice)
+	}
+	b.Roller = brd.White
+	if dice.Intn(2) == 1 {
+		b.Roller = brd.Red
+	}
+	var positions []brd.Board
+	candidates := b.LegalContinuations()
+	for {
+		choice := candidates[0]
+		if len(candidates) > 1 {
+			if analyzed := chooser(candidates); len(analyzed) > 0 {
+				choice = analyzed[0].Board
+			}
+		}
+		b = *choice
+		brd.OptionallyReturnBoardsToPool(candidates, &b)
+		positions = append(positions, b)
+		if _, over := analysis.FinalOutcomes(&b); over {
+			return positions
+		}
+		candidates = b.ContinuationsForRoll(roll(dice))
+	}
+}
+
+func roll(r *rand.Rand) brd.Roll {
+	x := r.Intn(6 * 6)
+	high, low := brd.Die(x%6+1), brd.Die(x/6+1)
+	if high < low {
+		high, low = low, high
+	}
+	if high == low {
+		return brd.Roll{high, high, high, high}
+	}
+	return brd.Roll{high, low}
+}
+
+// Converts o, which is for b.Roller, to White's point of view or vice versa.
+func forWhite(o analysis.Outcomes, b *brd.Board) analysis.Outcomes {
+	if b.Roller != brd.White {
+		return o.Flip()
+	}
+	return o
+}
+
+// Returns a times x plus b times y.
+func mix(a float32, x analysis.Outcomes, b float32, y analysis.Outcomes) analysis.Outcomes {
+	return analysis.Outcomes{
+		Win:            float64(a)*x.Win + float64(b)*y.Win,
+		WinGammon:      float64(a)*x.WinGammon + float64(b)*y.WinGammon,
+		WinBackgammon:  float64(a)*x.WinBackgammon + float64(b)*y.WinBackgammon,
+		LoseGammon:     float64(a)*x.LoseGammon + float64(b)*y.LoseGammon,
+		LoseBackgammon: float64(a)*x.LoseBackgammon + float64(b)*y.LoseBackgammon,
+	}
+}
+
+// The inverse of outcomes().
+func targets(o analysis.Outcomes) (y [NumOutputs]float32) {
+	y[Win] = float32(o.Win)
+	y[WinGammon] = float32(o.WinGammon)
+	y[WinBackgammon] = float32(o.WinBackgammon)
+	y[LoseGammon] = float32(o.LoseGammon)
+	y[LoseBackgammon] = float32(o.LoseBackgammon)
+	return
+}
+
+// Plays e against ai.MakePlayerConservative(0, nil), alternating colors, and
+// returns e's points per game. The dice depend only on seed, so every
+// checkpoint faces the same dice until its choices differ.
+func benchmark(e *Evaluator, games int, seed int64) rollout.Stats {
+	var stats rollout.Stats
+	mine := ai.ChooserFromEvaluator(e)
+	conservative := ai.MakePlayerConservative(0, nil)
+	for i := 0; i < games; i++ {
+		dice := rand.New(rand.NewSource(seed*1000003 - 1 - int64(i)))
+		me := brd.White
+		if i%2 == 1 {
+			me = brd.Red
+		}
+		chooser := func(choices []*brd.Board) []brd.AnalyzedBoard {
+			if choices[0].Roller == me {
+				return mine(choices)
+			}
+			return conservative(choices)
+		}
+		positions := playGame(chooser, dice)
+		last := &positions[len(positions)-1]
+		o, _ := analysis.FinalOutcomes(last)
+		if last.Roller != me {
+			o = o.Flip()
+		}
+		stats.Add(o, o.Equity(last, me))
+	}
+	return stats
+}
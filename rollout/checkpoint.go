@@ -0,0 +1,264 @@
+package rollout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/chandler37/gobackgammon/brd"
+	bgjson "github.com/chandler37/gobackgammon/json"
+)
+
+// A rollout in progress, which has played trials Settings.FirstTrial through
+// Settings.FirstTrial+Done-1 of Settings.Trials.
+//
+// The dice of a trial depend only on the Settings and the trial's number, so
+// there is no other RNG state to save. The choosers can't be saved, so
+// whoever resumes must supply the same ones, and the Evaluator is saved only
+// by name. (Choosers that use math/rand, as those of package ai do, won't
+// make the same choices as before.)
+type Checkpoint struct {
+	Board    *brd.Board
+	Settings Settings
+	Done     int
+	Stats    Stats
+	// Names Settings.Evaluator, as Job.Evaluator does, so that resuming and
+	// MergeCheckpoints() can tell rollouts with different Evaluators apart.
+	Evaluator string
+}
+
+// Example: {"b":"{\"wd\":1,\"rd\":1,\"p\":\"r\",\"p0\":\"W14\",\"p1\":\"r\",\"p19\":\"W\",\"p25\":\"r14\"}","t":60,"sd":37,"q":1,"d":60,"s":{"n":60,"s":-24,"ss":60,"w":18}}
+type compactCheckpoint struct {
+	Board             string       `json:"b"`
+	Trials            int          `json:"t,omitempty"`
+	FirstTrial        int64        `json:"f,omitempty"`
+	Seed              int64        `json:"sd,omitempty"`
+	QuasiRandomPlies  int          `json:"q,omitempty"`
+	DuplicateDice     int          `json:"dd,omitempty"`
+	VarianceReduction int          `json:"vr,omitempty"`
+	TruncatePlies     int          `json:"tp,omitempty"`
+	Evaluator         string       `json:"e,omitempty"`
+	Done              int          `json:"d,omitempty"`
+	Stats             compactStats `json:"s"`
+}
+
+type compactStats struct {
+	Trials         int     `json:"n,omitempty"`
+	Sum            float64 `json:"s,omitempty"`
+	SumSquares     float64 `json:"ss,omitempty"`
+	Win            float64 `json:"w,omitempty"`
+	WinGammon      float64 `json:"wg,omitempty"`
+	WinBackgammon  float64 `json:"wb,omitempty"`
+	LoseGammon     float64 `json:"lg,omitempty"`
+	LoseBackgammon float64 `json:"lb,omitempty"`
+}
+
+func makeCompactStats(s Stats) compactStats {
+	return compactStats{
+		Trials:         s.Trials,
+		Sum:            s.Sum,
+		SumSquares:     s.SumSquares,
+		Win:            s.Totals.Win,
+		WinGammon:      s.Totals.WinGammon,
+		WinBackgammon:  s.Totals.WinBackgammon,
+		LoseGammon:     s.Totals.LoseGammon,
+		LoseBackgammon: s.Totals.LoseBackgammon,
+	}
+}
+
+func (cs compactStats) stats() (s Stats) {
+	s.Trials = cs.Trials
+	s.Sum = cs.Sum
+	s.SumSquares = cs.SumSquares
+	s.Totals.Win = cs.Win
+	s.Totals.WinGammon = cs.WinGammon
+	s.Totals.WinBackgammon = cs.WinBackgammon
+	s.Totals.LoseGammon = cs.LoseGammon
+	s.Totals.LoseBackgammon = cs.LoseBackgammon
+	return
+}
+
+// Serializes all but the choosers, the Evaluator, and Settings.Workers.
+func (c *Checkpoint) Serialize() (string, error) {
+	board, err := bgjson.Serialize(c.Board)
+	if err != nil {
+		return "", err
+	}
+	cc := compactCheckpoint{
+		Board:            board,
+		Trials:           c.Settings.Trials,
+		FirstTrial:       c.Settings.FirstTrial,
+		Seed:             c.Settings.Seed,
+		QuasiRandomPlies: c.Settings.QuasiRandomPlies,
+		TruncatePlies:    c.Settings.TruncatePlies,
+		Evaluator:        c.Evaluator,
+		Done:             c.Done,
+		Stats:            makeCompactStats(c.Stats),
+	}
+	if c.Settings.DuplicateDice {
+		cc.DuplicateDice = 1
+	}
+	if c.Settings.VarianceReduction {
+		cc.VarianceReduction = 1
+	}
+	x, err := json.Marshal(cc)
+	if err != nil {
+		panic(err)
+	}
+	return string(x), nil
+}
+
+func DeserializeCheckpoint(s string) (*Checkpoint, error) {
+	cc := compactCheckpoint{}
+	if err := json.Unmarshal([]byte(s), &cc); err != nil {
+		return nil, err
+	}
+	b, err := bgjson.Deserialize(cc.Board)
+	if err != nil {
+		return nil, fmt.Errorf("bad board in %v: %v", s, err)
+	}
+	if cc.Trials < 0 || cc.FirstTrial < 0 || cc.QuasiRandomPlies < 0 || cc.Done < 0 || cc.Done > cc.Trials || cc.Stats.Trials != cc.Done {
+		return nil, fmt.Errorf("bad trials in %v", s)
+	}
+	c := &Checkpoint{
+		Board: b,
+		Settings: Settings{
+			Trials:            cc.Trials,
+			FirstTrial:        cc.FirstTrial,
+			Seed:              cc.Seed,
+			QuasiRandomPlies:  cc.QuasiRandomPlies,
+			DuplicateDice:     cc.DuplicateDice != 0,
+			VarianceReduction: cc.VarianceReduction != 0,
+			TruncatePlies:     cc.TruncatePlies,
+		},
+		Done:      cc.Done,
+		Stats:     cc.Stats.stats(),
+		Evaluator: cc.Evaluator,
+	}
+	return c, nil
+}
+
+// Writes c to path by way of a temporary file so that a crash leaves the old
+// checkpoint intact.
+func (c *Checkpoint) Save(path string) error {
+	s, err := c.Serialize()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(s), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	s, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DeserializeCheckpoint(string(s))
+}
+
+// Like Rollout() but saves a Checkpoint to path after every `every` trials.
+// If path already holds a Checkpoint of the same position with the same
+// Settings and Evaluator, picks up where it left off. Settings.Trials may
+// differ, which lets you extend a finished rollout.
+//
+// Settings.Evaluator is ignored in favor of the one evaluator names, as with
+// Job.Evaluator, so that the Checkpoint can record it.
+func RolloutWithCheckpoints(b *brd.Board, s Settings, evaluator string, path string, every int) (Stats, error) {
+	if every < 1 {
+		panic("bad every")
+	}
+	var err error
+	if s.Evaluator, err = evaluatorNamed(evaluator); err != nil {
+		return Stats{}, err
+	}
+	c := &Checkpoint{Board: b, Settings: s, Evaluator: evaluator}
+	if old, err := LoadCheckpoint(path); err == nil {
+		if err := old.resumableAs(c); err != nil {
+			return Stats{}, fmt.Errorf("cannot resume %v: %v", path, err)
+		}
+		c.Done, c.Stats = old.Done, old.Stats
+	} else if !os.IsNotExist(err) {
+		return Stats{}, err
+	}
+	for c.Done < s.Trials {
+		batch := s
+		batch.FirstTrial += int64(c.Done)
+		if batch.Trials = s.Trials - c.Done; batch.Trials > every {
+			batch.Trials = every
+		}
+		c.Stats.Merge(Rollout(b, batch))
+		c.Done += batch.Trials
+		if err := c.Save(path); err != nil {
+			return c.Stats, err
+		}
+	}
+	return c.Stats, nil
+}
+
+// Returns an error unless c can continue as o, which is the same rollout
+// save perhaps for more trials.
+func (c *Checkpoint) resumableAs(o *Checkpoint) error {
+	if err := c.samePosition(o); err != nil {
+		return err
+	}
+	x, y := c.Settings, o.Settings
+	if x.FirstTrial != y.FirstTrial || x.Seed != y.Seed || !sameEstimator(&x, &y) {
+		return fmt.Errorf("the Settings differ")
+	}
+	if c.Evaluator != o.Evaluator {
+		return fmt.Errorf("the Evaluators differ: %q vs. %q", c.Evaluator, o.Evaluator)
+	}
+	if c.Done > y.Trials {
+		return fmt.Errorf("%d trials are already done", c.Done)
+	}
+	return nil
+}
+
+func (c *Checkpoint) samePosition(o *Checkpoint) error {
+	x, err := bgjson.Serialize(c.Board)
+	if err != nil {
+		return err
+	}
+	y, err := bgjson.Serialize(o.Board)
+	if err != nil {
+		return err
+	}
+	if x != y {
+		return fmt.Errorf("the positions differ: %v vs. %v", x, y)
+	}
+	return nil
+}
+
+// Whether rollouts with x and y estimate the same thing with the same
+// variance, Evaluators aside.
+func sameEstimator(x, y *Settings) bool {
+	return x.QuasiRandomPlies == y.QuasiRandomPlies && x.DuplicateDice == y.DuplicateDice && x.VarianceReduction == y.VarianceReduction && x.TruncatePlies == y.TruncatePlies
+}
+
+// Combines independent rollouts of the same position. They must have the same
+// Settings and Evaluator but for Trials and FirstTrial, and they must not have
+// played the same trials with the same Seed, or they would share dice.
+func MergeCheckpoints(checkpoints ...*Checkpoint) (Stats, error) {
+	var total Stats
+	for i, c := range checkpoints {
+		for j, o := range checkpoints[:i] {
+			if err := c.samePosition(o); err != nil {
+				return Stats{}, err
+			}
+			x, y := c.Settings, o.Settings
+			if !sameEstimator(&x, &y) || c.Evaluator != o.Evaluator {
+				return Stats{}, fmt.Errorf("checkpoints %d and %d have different Settings", j, i)
+			}
+			if x.Seed == y.Seed && x.FirstTrial < y.FirstTrial+int64(o.Done) && y.FirstTrial < x.FirstTrial+int64(c.Done) {
+				return Stats{}, fmt.Errorf("checkpoints %d and %d share dice", j, i)
+			}
+		}
+		total.Merge(c.Stats)
+	}
+	return total, nil
+}
@@ -0,0 +1,346 @@
+package rollout
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/chandler37/gobackgammon/ai"
+	"github.com/chandler37/gobackgammon/brd"
+	bgjson "github.com/chandler37/gobackgammon/json"
+)
+
+// Distributed rollouts: a coordinator, Distribute(), hands out ranges of
+// trials to worker processes, each running Serve(), over any connection such
+// as TCP or a worker's stdin and stdout. The coordinator sends a job, one
+// JSON object per line:
+//
+//   {"b":"<json.Serialize() of the Board>","w":{"n":"racer"},"r":{"n":"conservative","p":1},"e":"race","f":72,"t":12,"sd":37,"q":1,"rp":6}
+//
+// and the worker replies with partial Stats for consecutive trials as it
+// plays them, one JSON object per line, here for trials 72 through 77 and
+// then 78 through 83:
+//
+//   {"f":72,"s":{"n":6,"s":2,"ss":6,"w":4}}
+//   {"f":78,"s":{"n":6,"s":-2,"ss":6,"w":2}}
+//
+// or with {"err":"..."}. Since the dice of a trial depend only on the Settings
+// and the trial's number, the coordinator can give the trials of a worker
+// that dies to another, which plays them with the same dice. But every
+// chooser that ChooserSettings can name breaks ties with math/rand's global
+// source, so the results may differ from those of an undisturbed run, just as
+// they may differ from run to run.
+
+// Names a brd.Chooser so that a worker can make one.
+type ChooserSettings struct {
+	Name  string // "racer", "random", or "conservative"
+	Plies int    // the foresight of "conservative"
+}
+
+func (c ChooserSettings) Chooser() (brd.Chooser, error) {
+	switch c.Name {
+	case "racer":
+		return ai.PlayerRacer, nil
+	case "random":
+		return ai.PlayerRandom, nil
+	case "conservative":
+		if c.Plies < 0 {
+			return nil, fmt.Errorf("bad plies %d", c.Plies)
+		}
+		return ai.MakePlayerConservative(uint64(c.Plies), nil), nil
+	}
+	return nil, fmt.Errorf("unknown chooser %q", c.Name)
+}
+
+// "" for none, "race", or "conservative"
+func evaluatorNamed(name string) (ai.Evaluator, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "race":
+		return ai.RaceEvaluator, nil
+	case "conservative":
+		return ai.ConservativeEvaluator, nil
+	}
+	return nil, fmt.Errorf("unknown evaluator %q", name)
+}
+
+// A rollout that can be described to a worker. Settings.Trials and
+// Settings.FirstTrial give the range of trials; the choosers, Evaluator, and
+// Workers of Settings are ignored in favor of White, Red, and Evaluator.
+type Job struct {
+	Board      *brd.Board
+	Settings   Settings
+	White, Red ChooserSettings
+	Evaluator  string // see evaluatorNamed()
+}
+
+// The Settings with the choosers and Evaluator filled in.
+func (j *Job) settings() (s Settings, err error) {
+	s = j.Settings
+	if s.White, err = j.White.Chooser(); err != nil {
+		return
+	}
+	if s.Red, err = j.Red.Chooser(); err != nil {
+		return
+	}
+	s.Evaluator, err = evaluatorNamed(j.Evaluator)
+	return
+}
+
+type compactChooser struct {
+	Name  string `json:"n"`
+	Plies int    `json:"p,omitempty"`
+}
+
+type compactJob struct {
+	Board             string         `json:"b"`
+	White             compactChooser `json:"w"`
+	Red               compactChooser `json:"r"`
+	Evaluator         string         `json:"e,omitempty"`
+	FirstTrial        int64          `json:"f,omitempty"`
+	Trials            int            `json:"t,omitempty"`
+	Seed              int64          `json:"sd,omitempty"`
+	QuasiRandomPlies  int            `json:"q,omitempty"`
+	DuplicateDice     int            `json:"dd,omitempty"`
+	VarianceReduction int            `json:"vr,omitempty"`
+	TruncatePlies     int            `json:"tp,omitempty"`
+	ReportEvery       int            `json:"rp,omitempty"`
+}
+
+type compactPartial struct {
+	FirstTrial int64         `json:"f,omitempty"`
+	Stats      *compactStats `json:"s,omitempty"`
+	Error      string        `json:"err,omitempty"`
+}
+
+func (j *Job) compact(reportEvery int) (*compactJob, error) {
+	board, err := bgjson.Serialize(j.Board)
+	if err != nil {
+		return nil, err
+	}
+	cj := &compactJob{
+		Board:            board,
+		White:            compactChooser{j.White.Name, j.White.Plies},
+		Red:              compactChooser{j.Red.Name, j.Red.Plies},
+		Evaluator:        j.Evaluator,
+		FirstTrial:       j.Settings.FirstTrial,
+		Trials:           j.Settings.Trials,
+		Seed:             j.Settings.Seed,
+		QuasiRandomPlies: j.Settings.QuasiRandomPlies,
+		TruncatePlies:    j.Settings.TruncatePlies,
+		ReportEvery:      reportEvery,
+	}
+	if j.Settings.DuplicateDice {
+		cj.DuplicateDice = 1
+	}
+	if j.Settings.VarianceReduction {
+		cj.VarianceReduction = 1
+	}
+	return cj, nil
+}
+
+func (cj *compactJob) job() (*Job, error) {
+	b, err := bgjson.Deserialize(cj.Board)
+	if err != nil {
+		return nil, fmt.Errorf("bad board: %v", err)
+	}
+	return &Job{
+		Board: b,
+		Settings: Settings{
+			FirstTrial:        cj.FirstTrial,
+			Trials:            cj.Trials,
+			Seed:              cj.Seed,
+			QuasiRandomPlies:  cj.QuasiRandomPlies,
+			DuplicateDice:     cj.DuplicateDice != 0,
+			VarianceReduction: cj.VarianceReduction != 0,
+			TruncatePlies:     cj.TruncatePlies,
+		},
+		White:     ChooserSettings{cj.White.Name, cj.White.Plies},
+		Red:       ChooserSettings{cj.Red.Name, cj.Red.Plies},
+		Evaluator: cj.Evaluator,
+	}, nil
+}
+
+// The worker's side: plays the jobs that arrive on conn until it closes,
+// using all the CPUs.
+func Serve(conn io.ReadWriter) error {
+	decoder := json.NewDecoder(conn)
+	encoder := json.NewEncoder(conn)
+	for {
+		var cj compactJob
+		if err := decoder.Decode(&cj); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := serveJob(&cj, encoder); err != nil {
+			return err
+		}
+	}
+}
+
+// Returns an error only if we can't talk to the coordinator.
+func serveJob(cj *compactJob, encoder *json.Encoder) error {
+	var s Settings
+	job, err := cj.job()
+	if err == nil {
+		s, err = job.settings()
+	}
+	if err == nil && (s.Trials < 1 || s.FirstTrial < 0 || cj.ReportEvery < 1 || s.QuasiRandomPlies < 0 || (s.Evaluator == nil && (s.VarianceReduction || s.TruncatePlies > 0))) {
+		err = fmt.Errorf("bad job")
+	}
+	if err != nil {
+		return encoder.Encode(compactPartial{Error: err.Error()})
+	}
+	for done := 0; done < s.Trials; done += cj.ReportEvery {
+		batch := s
+		batch.FirstTrial += int64(done)
+		if batch.Trials = s.Trials - done; batch.Trials > cj.ReportEvery {
+			batch.Trials = cj.ReportEvery
+		}
+		stats := makeCompactStats(Rollout(job.Board, batch))
+		partial := compactPartial{FirstTrial: batch.FirstTrial, Stats: &stats}
+		if err := encoder.Encode(partial); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Accepts connections from coordinators at addr, e.g., ":3737", forever and
+// serves each.
+func ListenAndServe(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			Serve(conn)
+		}()
+	}
+}
+
+// An in-process worker, for tests and for using this process's CPUs
+// alongside remote workers.
+func LocalWorker() io.ReadWriteCloser {
+	coordinator, worker := net.Pipe()
+	go func() {
+		defer worker.Close()
+		Serve(worker)
+	}()
+	return coordinator
+}
+
+// A range of trials.
+type trialRange struct {
+	first int64
+	n     int
+}
+
+// The coordinator's side: rolls out job.Board by sending ranges of
+// jobTrials trials to the workers, which report every reportEvery trials, and
+// returns the merged Stats. A worker that fails, e.g., by closing its
+// connection, loses its unreported trials to the others. Closes all the
+// workers.
+//
+// Returns an error if all the workers fail before all the trials are played.
+//
+// TODO(chandler37): Time out workers that hang.
+func Distribute(job Job, workers []io.ReadWriteCloser, jobTrials, reportEvery int) (Stats, error) {
+	if jobTrials < 1 || reportEvery < 1 || job.Settings.Trials < 0 {
+		panic("bad arguments")
+	}
+	if _, err := job.settings(); err != nil {
+		return Stats{}, err
+	}
+	var numRanges int
+	for done := 0; done < job.Settings.Trials; done += jobTrials {
+		numRanges++
+	}
+	// There are never more pending ranges than there were at the start.
+	pending := make(chan trialRange, numRanges)
+	for done := 0; done < job.Settings.Trials; done += jobTrials {
+		r := trialRange{job.Settings.FirstTrial + int64(done), job.Settings.Trials - done}
+		if r.n > jobTrials {
+			r.n = jobTrials
+		}
+		pending <- r
+	}
+	var mu sync.Mutex
+	var total Stats
+	var lastErr error
+	remaining := job.Settings.Trials
+	if remaining == 0 {
+		close(pending)
+	}
+	// Returns an error if the worker failed, having put back the trials it
+	// didn't report.
+	work := func(encoder *json.Encoder, decoder *json.Decoder, r trialRange) error {
+		j := job
+		j.Settings.FirstTrial, j.Settings.Trials = r.first, r.n
+		cj, err := j.compact(reportEvery)
+		if err != nil {
+			panic(err) // job.Board serialized fine before
+		}
+		if err := encoder.Encode(cj); err != nil {
+			pending <- r
+			return err
+		}
+		for r.n > 0 {
+			var partial compactPartial
+			if err := decoder.Decode(&partial); err != nil {
+				pending <- r
+				return err
+			}
+			var stats Stats
+			if partial.Stats != nil {
+				stats = partial.Stats.stats()
+			}
+			if partial.Error != "" || partial.FirstTrial != r.first || stats.Trials < 1 || stats.Trials > r.n {
+				pending <- r
+				return fmt.Errorf("bad partial %+v for trials starting with %d", partial, r.first)
+			}
+			r.first += int64(stats.Trials)
+			r.n -= stats.Trials
+			mu.Lock()
+			total.Merge(stats)
+			remaining -= stats.Trials
+			if remaining == 0 {
+				close(pending)
+			}
+			mu.Unlock()
+		}
+		return nil
+	}
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func(conn io.ReadWriteCloser) {
+			defer wg.Done()
+			defer conn.Close()
+			encoder, decoder := json.NewEncoder(conn), json.NewDecoder(conn)
+			for r := range pending {
+				if err := work(encoder, decoder, r); err != nil {
+					mu.Lock()
+					lastErr = err
+					mu.Unlock()
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	if remaining > 0 {
+		return total, fmt.Errorf("all workers failed with %d trials to go; the last error was %v", remaining, lastErr)
+	}
+	return total, nil
+}
@@ -0,0 +1,114 @@
+package rollout
+
+import (
+	"math"
+	"sort"
+
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// Settings for Rank().
+type RankSettings struct {
+	// Rollout.Trials is the most trials any candidate gets.
+	Rollout Settings
+	// Ranks all the candidates cheaply so that we roll out only the best
+	// TopK. nil means Rollout's chooser for the player on roll.
+	Prefilter brd.Chooser
+	TopK      int // zero means all the candidates
+	// The candidates are rolled out in batches of this many trials. After
+	// each batch we stop rolling out those that are clearly worse than the
+	// leader. Zero means one cycle of the quasi-random (and duplicate) dice,
+	// but at least 36.
+	BatchTrials int
+}
+
+func (s *RankSettings) batchTrials() int {
+	if s.BatchTrials > 0 {
+		return s.BatchTrials
+	}
+	n := 36
+	for i := 1; i < s.Rollout.QuasiRandomPlies; i++ {
+		n *= 36
+	}
+	if s.Rollout.DuplicateDice {
+		n *= 2
+	}
+	return n
+}
+
+// Rolls out the ways b.Roller can play b.Roll, i.e., b.LegalContinuations(),
+// and ranks them, best first. Each candidate's Analysis is its Stats. Every
+// candidate uses the same dice in its Nth trial, so the differences between
+// candidates are less noisy than the Stats suggest.
+//
+// Only the top RankSettings.TopK candidates per RankSettings.Prefilter are
+// rolled out and returned. Those that fall clearly behind, i.e., whose
+// deficit exceeds 1.96 standard errors of the difference, stop early and
+// have fewer trials.
+func Rank(b *brd.Board, s RankSettings) []brd.AnalyzedBoard {
+	if s.TopK < 0 {
+		panic("bad RankSettings")
+	}
+	candidates := b.LegalContinuations()
+	prefilter := s.Prefilter
+	if prefilter == nil {
+		prefilter = s.Rollout.chooser(b.Roller)
+	}
+	var top []*brd.Board
+	if len(candidates) == 1 {
+		top = candidates
+	} else {
+		for _, a := range prefilter(candidates) {
+			top = append(top, a.Board)
+		}
+	}
+	if s.TopK > 0 && len(top) > s.TopK {
+		top = top[:s.TopK]
+	}
+	stats := make([]Stats, len(top))
+	alive := make([]bool, len(top))
+	for i := range alive {
+		alive[i] = true
+	}
+	batch := s.batchTrials()
+	for done := 0; done < s.Rollout.Trials; done += batch {
+		settings := s.Rollout
+		settings.FirstTrial += int64(done)
+		if settings.Trials = s.Rollout.Trials - done; settings.Trials > batch {
+			settings.Trials = batch
+		}
+		for i, c := range top {
+			if alive[i] {
+				stats[i].Merge(Rollout(c, settings))
+			}
+		}
+		prune(stats, alive)
+	}
+	result := make([]brd.AnalyzedBoard, len(top))
+	for i, c := range top {
+		result[i] = brd.AnalyzedBoard{Board: c, Analysis: stats[i]}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Analysis.(Stats).Equity() > result[j].Analysis.(Stats).Equity()
+	})
+	return result
+}
+
+// Marks as dead those of the living whose equity is clearly below the best.
+func prune(stats []Stats, alive []bool) {
+	best := -1
+	for i, s := range stats {
+		if alive[i] && (best < 0 || s.Equity() > stats[best].Equity()) {
+			best = i
+		}
+	}
+	for i, s := range stats {
+		if !alive[i] || i == best {
+			continue
+		}
+		stdErr := math.Hypot(s.StdErr(), stats[best].StdErr())
+		if stats[best].Equity()-s.Equity() > z95*stdErr {
+			alive[i] = false
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package rollout estimates the equity of a backgammon position by Monte
+// Carlo simulation: it plays the position out many times and averages the
+// results.
+//
+// Each trial uses its own dice, seeded from Settings.Seed and the trial's
+// number, so a rollout's results don't depend on how many workers share the
+// work or on math/rand's global source. (Choosers that break ties with
+// math/rand, as those of package ai do, are another matter.)
+//
+// Plain rollouts converge slowly, so Settings offers several ways to reduce
+// the variance: quasi-random dice for the first plies, duplicate dice, and
+// luck adjustment by an ai.Evaluator. Truncation, where the Evaluator scores
+// the position after a fixed number of plies, trades accuracy for speed.
+//
+// The cube is ignored.
+package rollout
+
+import (
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/chandler37/gobackgammon/ai"
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+type Settings struct {
+	Trials     int
+	FirstTrial int64 // Rollout() plays trials FirstTrial through FirstTrial+Trials-1
+	// Each plays for its color. They must be safe for concurrent use.
+	White, Red brd.Chooser
+	Workers    int // zero means one per CPU
+	Seed       int64
+
+	// If positive, the first rolls rotate through all 36**QuasiRandomPlies
+	// combinations in order instead of being random, so Trials should be a
+	// multiple of that (times two with DuplicateDice).
+	QuasiRandomPlies int
+	// If true, trials come in pairs. The second of a pair skips the first
+	// roll of the first's dice, so each player gets the rolls the other got.
+	DuplicateDice bool
+	// If true, Evaluator estimates the luck of each roll, i.e., how much
+	// better it is for the player to move than the average roll, and we
+	// subtract the luck from each trial's equity (but not its Outcomes).
+	VarianceReduction bool
+	// If positive, a trial stops after this many rolls and Evaluator scores
+	// the position.
+	TruncatePlies int
+	Evaluator     ai.Evaluator
+}
+
+func (s *Settings) chooser(player brd.Checker) brd.Chooser {
+	if player == brd.White {
+		return s.White
+	}
+	return s.Red
+}
+
+// Plays out b, a Board returned by LegalContinuations(), Settings.Trials
+// times, in parallel, and returns the statistics from the point of view of
+// b.Roller, which has just moved. In a match the equity of each trial is
+// twice b.Roller's match winning chances minus one; see
+// analysis.Outcomes.Equity().
+func Rollout(b *brd.Board, s Settings) Stats {
+	if s.Trials < 0 || s.FirstTrial < 0 || s.White == nil || s.Red == nil || s.QuasiRandomPlies < 0 {
+		panic("bad Settings")
+	}
+	if (s.VarianceReduction || s.TruncatePlies > 0) && s.Evaluator == nil {
+		panic("Settings need an Evaluator")
+	}
+	workers := s.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	next := s.FirstTrial - 1
+	results := make([]Stats, workers)
+	var wg sync.WaitGroup
+	for w := range results {
+		wg.Add(1)
+		go func(stats *Stats) {
+			defer wg.Done()
+			d := newDice(s.QuasiRandomPlies)
+			for {
+				trial := atomic.AddInt64(&next, 1)
+				if trial >= s.FirstTrial+int64(s.Trials) {
+					return
+				}
+				d.seed(&s, trial)
+				o, luck := playOut(b, &s, d)
+				stats.Add(o, o.Equity(b, b.Roller)-luck)
+			}
+		}(&results[w])
+	}
+	wg.Wait()
+	var total Stats
+	for _, r := range results {
+		total.Merge(r)
+	}
+	return total
+}
+
+// A worker's source of dice.
+type dice struct {
+	source rand.Source
+	rand   *rand.Rand
+	quasi  []int // the first rolls of the trial, see roll()
+	ply    int   // how many rolls so far
+}
+
+func newDice(quasiRandomPlies int) *dice {
+	source := rand.NewSource(0)
+	return &dice{source: source, rand: rand.New(source), quasi: make([]int, quasiRandomPlies)}
+}
+
+// Starts the dice for the given trial.
+func (d *dice) seed(s *Settings, trial int64) {
+	pair, skip := trial, int64(0)
+	if s.DuplicateDice {
+		pair, skip = trial/2, trial%2
+	}
+	d.source.Seed(s.Seed*1000003 + pair)
+	// The first roll cycles fastest.
+	for i := range d.quasi {
+		d.quasi[i] = int(pair % 36)
+		pair /= 36
+	}
+	d.ply = 0
+	for ; skip > 0; skip-- {
+		d.roll()
+	}
+}
+
+func (d *dice) roll() brd.Roll {
+	var x int
+	if d.ply < len(d.quasi) {
+		x = d.quasi[d.ply]
+	} else {
+		x = d.rand.Intn(6 * 6)
+	}
+	d.ply++
+	high, low := brd.Die(x%6+1), brd.Die(x/6+1)
+	if high < low {
+		high, low = low, high
+	}
+	if high == low {
+		return brd.Roll{high, high, high, high}
+	}
+	return brd.Roll{high, low}
+}
+
+// Plays out one game starting with start and returns start.Roller's
+// Outcomes and, if Settings.VarianceReduction, start.Roller's total luck.
+func playOut(start *brd.Board, s *Settings, d *dice) (o analysis.Outcomes, luck float64) {
+	b := *start
+	for plies := 0; ; plies++ {
+		over := false
+		if o, over = analysis.FinalOutcomes(&b); !over && s.TruncatePlies > 0 && plies >= s.TruncatePlies && b.Bonus == brd.ZeroDie {
+			o, over = s.Evaluator.Evaluate(&b), true
+		}
+		if over {
+			if b.Roller != start.Roller {
+				o = o.Flip()
+			}
+			return
+		}
+		var candidates []*brd.Board
+		if b.Bonus != brd.ZeroDie {
+			// The Roller plays its Bonus doublet, which involves no dice.
+			plies--
+			b.TakeTurn(nil, nil)
+			candidates = b.LegalContinuations()
+		} else {
+			candidates = b.ContinuationsForRoll(d.roll())
+			if s.VarianceReduction {
+				l := rollLuck(&b, candidates, s.Evaluator)
+				if b.Roller == start.Roller {
+					// The other player is on roll.
+					l = -l
+				}
+				luck += l
+			}
+		}
+		choice := candidates[0]
+		if len(candidates) > 1 {
+			if analyzed := s.chooser(choice.Roller)(candidates); len(analyzed) > 0 {
+				choice = analyzed[0].Board
+			}
+		}
+		b = *choice
+		// After a Bonus, &b itself may be the no-op continuation.
+		brd.OptionallyReturnBoardsToPool(candidates, &b)
+	}
+}
+
+// Returns how much better, per e, the roll that led to candidates is for the
+// player on roll in b than the average roll.
+func rollLuck(b *brd.Board, candidates []*brd.Board, e ai.Evaluator) float64 {
+	average := 0.0
+	for _, wr := range brd.AllRolls() {
+		others := b.ContinuationsForRoll(wr.Roll)
+		average += wr.Probability() * bestEquity(others, e)
+		brd.OptionallyReturnBoardsToPool(others, nil)
+	}
+	return bestEquity(candidates, e) - average
+}
+
+// The equity, per e, of the best of the candidates for their Roller.
+func bestEquity(candidates []*brd.Board, e ai.Evaluator) float64 {
+	best := math.Inf(-1)
+	for _, c := range candidates {
+		o, over := analysis.FinalOutcomes(c)
+		if !over {
+			o = e.Evaluate(c)
+		}
+		best = math.Max(best, o.Equity(c, c.Roller))
+	}
+	return best
+}
@@ -0,0 +1,429 @@
+package rollout
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chandler37/gobackgammon/ai"
+	"github.com/chandler37/gobackgammon/analysis"
+	"github.com/chandler37/gobackgammon/brd"
+)
+
+// Red has just moved, leaving red on its own points and White, which is on
+// roll, white on its own. Each has borne off the rest of its fifteen
+// checkers.
+func newBearoff(red, white map[int]int) *brd.Board {
+	b := brd.New(false)
+	b.Roller = brd.Red
+	b.Roll = brd.Roll{}
+	b.Pips = brd.Points28{}
+	numRed, numWhite := 0, 0
+	for i, n := range red {
+		b.Pips[i].Reset(n, brd.Red)
+		numRed += n
+	}
+	for i, n := range white {
+		b.Pips[25-i].Reset(n, brd.White)
+		numWhite += n
+	}
+	b.Pips[brd.BorneOffRedPip].Reset(15-numRed, brd.Red)
+	b.Pips[brd.BorneOffWhitePip].Reset(15-numWhite, brd.White)
+	return b
+}
+
+func TestRollout(t *testing.T) {
+	settings := Settings{Trials: 4000, White: ai.PlayerRacer, Red: ai.PlayerRacer, Seed: 37}
+	// White bears off at once.
+	stats := Rollout(newBearoff(map[int]int{6: 1}, map[int]int{1: 1}), settings)
+	if x := stats.Summary(); x != "equity -1.000 ± 0.000 in 4000 trials: win 0.0% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("summary=%v", x)
+	}
+	// White, on roll, wins 75% of the time. See bearoff.TestTwoSided.
+	b := newBearoff(map[int]int{1: 1}, map[int]int{6: 1})
+	stats = Rollout(b, settings)
+	if x := stats.Summary(); x != "equity -0.490 ± 0.014 in 4000 trials: win 25.5% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("summary=%v", x)
+	}
+	if low, high := stats.ConfidenceInterval(); low > -0.5 || high < -0.5 {
+		t.Errorf("[%v, %v]", low, high)
+	}
+	// The number of workers changes nothing.
+	for _, workers := range []int{1, 3} {
+		settings.Workers = workers
+		if x := Rollout(b, settings); x != stats {
+			t.Errorf("workers=%d: %v", workers, x.Summary())
+		}
+	}
+	// White has three checkers back, so Red wins some gammons and even a
+	// backgammon or two. The conservative players break ties with math/rand,
+	// so for the sake of reproducibility we use only one worker.
+	b = newBearoff(map[int]int{6: 5, 5: 5, 4: 5}, map[int]int{6: 5, 5: 5, 4: 2, 22: 3})
+	conservative := ai.MakePlayerConservative(0, nil)
+	rand.Seed(37)
+	stats = Rollout(b, Settings{Trials: 1000, White: conservative, Red: conservative, Workers: 1, Seed: 37})
+	if x := stats.Summary(); x != "equity +1.070 ± 0.011 in 1000 trials: win 99.1% (gammon 8.6% backgammon 0.2%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("summary=%v", x)
+	}
+	o := stats.Outcomes()
+	if x := o.Win - o.WinGammon + 2*(o.WinGammon-o.WinBackgammon) + 3*o.WinBackgammon - (1 - o.Win - o.LoseGammon) - 2*(o.LoseGammon-o.LoseBackgammon) - 3*o.LoseBackgammon; math.Abs(x-stats.Equity()) > 1e-9 {
+		t.Errorf("equity=%v but the outcomes say %v", stats.Equity(), x)
+	}
+}
+
+func TestVarianceReduction(t *testing.T) {
+	// White, on roll, wins 75% of the time, and 36 quasi-random trials show
+	// each first roll once.
+	b := newBearoff(map[int]int{1: 1}, map[int]int{6: 1})
+	settings := Settings{Trials: 36, White: ai.PlayerRacer, Red: ai.PlayerRacer, Seed: 37, QuasiRandomPlies: 1, Evaluator: ai.RaceEvaluator}
+	if x := Rollout(b, settings).Summary(); x != "equity -0.500 ± 0.146 in 36 trials: win 25.0% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("summary=%v", x)
+	}
+	// The Evaluator knows that this race is all luck.
+	settings.VarianceReduction = true
+	if x := Rollout(b, settings).Summary(); x != "equity -0.500 ± 0.000 in 36 trials: win 25.0% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("summary=%v", x)
+	}
+	// After one roll, Red is on roll and sure to win.
+	settings.VarianceReduction = false
+	settings.TruncatePlies = 1
+	if x := Rollout(b, settings).Summary(); x != "equity -0.500 ± 0.146 in 36 trials: win 25.0% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("summary=%v", x)
+	}
+	// A plain rollout of 1296 trials says -0.120 ± 0.028.
+	b = newBearoff(map[int]int{6: 3, 5: 3, 4: 3, 8: 2}, map[int]int{6: 3, 5: 3, 4: 3, 9: 2})
+	settings = Settings{
+		Trials:            72,
+		White:             ai.PlayerRacer,
+		Red:               ai.PlayerRacer,
+		Seed:              37,
+		QuasiRandomPlies:  1,
+		DuplicateDice:     true,
+		VarianceReduction: true,
+		Evaluator:         ai.RaceEvaluator,
+	}
+	if x := Rollout(b, settings).Summary(); x != "equity -0.114 ± 0.005 in 72 trials: win 47.2% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("summary=%v", x)
+	}
+	func() {
+		defer func() {
+			if x := recover(); x != "Settings need an Evaluator" {
+				t.Errorf("recovered %v", x)
+			}
+		}()
+		settings.Evaluator = nil
+		Rollout(b, settings)
+	}()
+}
+
+func TestRank(t *testing.T) {
+	b := newBearoff(map[int]int{6: 3, 5: 3, 4: 3, 8: 2}, map[int]int{6: 3, 5: 3, 4: 3, 9: 2})
+	b.Roller, b.Roll = brd.White, brd.Roll{6, 1}
+	if x := len(b.LegalContinuations()); x != 5 {
+		t.Fatalf("%d candidates", x)
+	}
+	settings := RankSettings{
+		Rollout: Settings{
+			Trials:            180,
+			White:             ai.PlayerRacer,
+			Red:               ai.PlayerRacer,
+			Seed:              37,
+			QuasiRandomPlies:  1,
+			VarianceReduction: true,
+			Evaluator:         ai.RaceEvaluator,
+		},
+		TopK: 4,
+	}
+	var summaries []string
+	for _, a := range Rank(b, settings) {
+		if a.Board.Roller != brd.White {
+			t.Errorf("%v", a)
+		}
+		summaries = append(summaries, a.Analysis.Summary())
+	}
+	// The last two stopped early, after three and two batches of 36.
+	expected := []string{
+		"equity +0.051 ± 0.003 in 180 trials: win 43.9% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)",
+		"equity +0.048 ± 0.003 in 180 trials: win 44.4% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)",
+		"equity +0.035 ± 0.004 in 108 trials: win 45.4% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)",
+		"equity +0.035 ± 0.006 in 72 trials: win 45.8% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)",
+	}
+	if strings.Join(summaries, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("%q", summaries)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint")
+	b := newBearoff(map[int]int{1: 1}, map[int]int{6: 1})
+	settings := Settings{Trials: 100, White: ai.PlayerRacer, Red: ai.PlayerRacer, Seed: 37, QuasiRandomPlies: 1}
+	expected := Rollout(b, settings)
+	// We're interrupted after 60 trials and later resume.
+	settings.Trials = 60
+	if _, err := RolloutWithCheckpoints(b, settings, "", path, 25); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadCheckpoint(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x, err := c.Serialize(); err != nil || x != `{"b":"{\"wd\":1,\"rd\":1,\"p\":\"r\",\"p0\":\"W14\",\"p1\":\"r\",\"p19\":\"W\",\"p25\":\"r14\"}","t":60,"sd":37,"q":1,"d":60,"s":{"n":60,"s":-24,"ss":60,"w":18}}` {
+		t.Errorf("%v %v", x, err)
+	}
+	settings.Trials = 100
+	stats, err := RolloutWithCheckpoints(b, settings, "", path, 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != expected {
+		t.Errorf("%v vs. %v", stats.Summary(), expected.Summary())
+	}
+	if c, err = LoadCheckpoint(path); err != nil || c.Done != 100 || c.Stats != expected {
+		t.Errorf("%v %v", c, err)
+	}
+	settings.Seed = 38
+	if _, err := RolloutWithCheckpoints(b, settings, "", path, 25); err == nil || !strings.Contains(err.Error(), "the Settings differ") {
+		t.Errorf("%v", err)
+	}
+	// An independent run with another Seed.
+	other := &Checkpoint{Board: b, Settings: settings, Done: 100, Stats: Rollout(b, settings)}
+	stats, err = MergeCheckpoints(c, other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := stats.Summary(); x != "equity -0.460 ± 0.063 in 200 trials: win 27.0% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("%v", x)
+	}
+	if _, err := MergeCheckpoints(c, other, c); err == nil || err.Error() != "checkpoints 0 and 2 share dice" {
+		t.Errorf("%v", err)
+	}
+	// A run that differs only in TruncatePlies estimates something else.
+	truncated := settings
+	truncated.Seed = 39
+	truncated.TruncatePlies = 2
+	truncated.Evaluator = ai.RaceEvaluator
+	third := &Checkpoint{Board: b, Settings: truncated, Done: 100, Stats: Rollout(b, truncated), Evaluator: "race"}
+	if _, err := MergeCheckpoints(c, other, third); err == nil || err.Error() != "checkpoints 0 and 2 have different Settings" {
+		t.Errorf("%v", err)
+	}
+	// As does one with another Evaluator.
+	fourth := *third
+	fourth.Settings.Seed = 40
+	fourth.Evaluator = "conservative"
+	if _, err := MergeCheckpoints(third, &fourth); err == nil || err.Error() != "checkpoints 0 and 1 have different Settings" {
+		t.Errorf("%v", err)
+	}
+	// The same goes for Checkpoints on disk, which name their Evaluators.
+	racePath := filepath.Join(t.TempDir(), "race")
+	truncated.Trials = 10
+	if _, err := RolloutWithCheckpoints(b, truncated, "race", racePath, 5); err != nil {
+		t.Fatal(err)
+	}
+	truncated.Trials = 20
+	if _, err := RolloutWithCheckpoints(b, truncated, "conservative", racePath, 5); err == nil || !strings.Contains(err.Error(), `the Evaluators differ: "race" vs. "conservative"`) {
+		t.Errorf("%v", err)
+	}
+	conservativePath := filepath.Join(t.TempDir(), "conservative")
+	truncated.Seed = 41
+	if _, err := RolloutWithCheckpoints(b, truncated, "conservative", conservativePath, 5); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RolloutWithCheckpoints(b, truncated, "bogus", conservativePath, 5); err == nil || err.Error() != `unknown evaluator "bogus"` {
+		t.Errorf("%v", err)
+	}
+	fromRace, err := LoadCheckpoint(racePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromConservative, err := LoadCheckpoint(conservativePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromRace.Evaluator != "race" || fromConservative.Evaluator != "conservative" {
+		t.Errorf("%v %v", fromRace, fromConservative)
+	}
+	if _, err := MergeCheckpoints(fromRace, fromConservative); err == nil || err.Error() != "checkpoints 0 and 1 have different Settings" {
+		t.Errorf("%v", err)
+	}
+	if x, err := third.Serialize(); err != nil || !strings.Contains(x, `"tp":2,"e":"race"`) {
+		t.Errorf("%v %v", x, err)
+	} else if y, err := DeserializeCheckpoint(x); err != nil || y.Evaluator != "race" {
+		t.Errorf("%v %v", y, err)
+	}
+	if _, err := DeserializeCheckpoint(`{"b":"{}","t":60,"d":61}`); err == nil {
+		t.Errorf("expected an error")
+	}
+}
+
+// Writes n times, then fails.
+type dyingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (d *dyingWriter) Write(p []byte) (int, error) {
+	if d.n == 0 {
+		return 0, fmt.Errorf("dead")
+	}
+	d.n--
+	return d.w.Write(p)
+}
+
+// A worker that dies after reporting numPartials partial Stats.
+func dyingWorker(numPartials int) io.ReadWriteCloser {
+	coordinator, worker := net.Pipe()
+	go func() {
+		defer worker.Close()
+		var cj compactJob
+		if err := json.NewDecoder(worker).Decode(&cj); err != nil {
+			return
+		}
+		serveJob(&cj, json.NewEncoder(&dyingWriter{worker, numPartials}))
+	}()
+	return coordinator
+}
+
+func TestDistribute(t *testing.T) {
+	b := newBearoff(map[int]int{1: 1}, map[int]int{6: 1})
+	job := Job{
+		Board:    b,
+		Settings: Settings{Trials: 100, Seed: 37, QuasiRandomPlies: 1},
+		White:    ChooserSettings{Name: "racer"},
+		Red:      ChooserSettings{Name: "racer"},
+	}
+	settings, err := job.settings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// With a checker apiece, the racer never has a tie to break with
+	// math/rand, so the results don't depend on who plays which trials.
+	expected := Rollout(b, settings)
+	stats, err := Distribute(job, []io.ReadWriteCloser{LocalWorker(), LocalWorker(), LocalWorker()}, 30, 7)
+	if err != nil || stats != expected {
+		t.Errorf("%v %v vs. %v", err, stats.Summary(), expected.Summary())
+	}
+	// Two die mid-job, leaving their trials to the survivor.
+	stats, err = Distribute(job, []io.ReadWriteCloser{dyingWorker(1), LocalWorker(), dyingWorker(2)}, 30, 7)
+	if err != nil || stats != expected {
+		t.Errorf("%v %v vs. %v", err, stats.Summary(), expected.Summary())
+	}
+	stats, err = Distribute(job, []io.ReadWriteCloser{dyingWorker(1), dyingWorker(3)}, 30, 7)
+	if err == nil || err.Error() != "all workers failed with 72 trials to go; the last error was EOF" || stats.Trials != 28 {
+		t.Errorf("%v %v", err, stats.Summary())
+	}
+	job.Red.Name = "genius"
+	if _, err := Distribute(job, []io.ReadWriteCloser{LocalWorker()}, 30, 7); err == nil || err.Error() != `unknown chooser "genius"` {
+		t.Errorf("%v", err)
+	}
+
+	// The wire protocol
+	job.Red = ChooserSettings{Name: "conservative", Plies: 1}
+	job.Evaluator = "race"
+	job.Settings.FirstTrial, job.Settings.Trials = 72, 12
+	cj, err := job.compact(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	line, err := json.Marshal(cj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := string(line); x != `{"b":"{\"wd\":1,\"rd\":1,\"p\":\"r\",\"p0\":\"W14\",\"p1\":\"r\",\"p19\":\"W\",\"p25\":\"r14\"}","w":{"n":"racer"},"r":{"n":"conservative","p":1},"e":"race","f":72,"t":12,"sd":37,"q":1,"rp":6}` {
+		t.Errorf("%v", x)
+	}
+	worker := LocalWorker()
+	defer worker.Close()
+	reader := bufio.NewReader(worker)
+	replies := []string{
+		"{\"f\":72,\"s\":{\"n\":6,\"s\":2,\"ss\":6,\"w\":4}}\n{\"f\":78,\"s\":{\"n\":6,\"s\":-2,\"ss\":6,\"w\":2}}\n",
+		"{\"err\":\"bad board: bad Roller in {}\"}\n",
+	}
+	for i, request := range []string{string(line), `{"b":"{}","w":{"n":"racer"},"r":{"n":"racer"},"t":1,"rp":1}`} {
+		if _, err := fmt.Fprintln(worker, request); err != nil {
+			t.Fatal(err)
+		}
+		var lines []string
+		for {
+			reply, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines = append(lines, reply)
+			if strings.Contains(reply, "err") || strings.Contains(reply, `"f":78`) {
+				break
+			}
+		}
+		if x := strings.Join(lines, ""); x != replies[i] {
+			t.Errorf("%v", x)
+		}
+	}
+}
+
+func TestDice(t *testing.T) {
+	s := Settings{Seed: 37, QuasiRandomPlies: 2}
+	d := newDice(s.QuasiRandomPlies)
+	seen := map[[2]brd.Roll]int{}
+	for trial := int64(0); trial < 36*36; trial++ {
+		d.seed(&s, trial)
+		seen[[2]brd.Roll{d.roll(), d.roll()}]++
+	}
+	// 21 distinct rolls, doublets once in 36 and the others twice.
+	if len(seen) != 21*21 || seen[[2]brd.Roll{{1, 1, 1, 1}, {1, 1, 1, 1}}] != 1 || seen[[2]brd.Roll{{2, 1}, {2, 1}}] != 4 {
+		t.Errorf("%d %v", len(seen), seen[[2]brd.Roll{{2, 1}, {2, 1}}])
+	}
+	s = Settings{Seed: 37, DuplicateDice: true}
+	d = newDice(s.QuasiRandomPlies)
+	var first [10]brd.Roll
+	d.seed(&s, 6)
+	for i := range first {
+		first[i] = d.roll()
+	}
+	d.seed(&s, 7)
+	for i := 1; i < len(first); i++ {
+		if x := d.roll(); x != first[i] {
+			t.Errorf("%d: %v vs. %v", i, x, first[i])
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	var s, t1, t2 Stats
+	for i, x := range []float64{1, -1, 2, 1, -3, 1} {
+		s.Add(outcomesOf(x), x)
+		if i%2 == 0 {
+			t1.Add(outcomesOf(x), x)
+		} else {
+			t2.Add(outcomesOf(x), x)
+		}
+	}
+	t1.Merge(t2)
+	if t1 != s {
+		t.Errorf("%v vs. %v", t1, s)
+	}
+	if x := fmt.Sprintf("%.4f %.4f", s.Equity(), s.StdErr()); x != "0.1667 0.7491" {
+		t.Errorf("%v", x)
+	}
+	if x := (Stats{}).Summary(); x != "equity +0.000 ± 0.000 in 0 trials: win 0.0% (gammon 0.0% backgammon 0.0%) lose gammon 0.0% (backgammon 0.0%)" {
+		t.Errorf("%v", x)
+	}
+}
+
+// The Outcomes of a money game that ended with the given points.
+func outcomesOf(points float64) (o analysis.Outcomes) {
+	if points > 0 {
+		o.Win = 1
+		o.WinGammon = math.Max(0, math.Min(1, points-1))
+		o.WinBackgammon = math.Max(0, points-2)
+		return
+	}
+	o.LoseGammon = math.Max(0, math.Min(1, -points-1))
+	o.LoseBackgammon = math.Max(0, -points-2)
+	return
+}
@@ -0,0 +1,86 @@
+package rollout
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/chandler37/gobackgammon/analysis"
+)
+
+// The z-score of a two-sided 95% confidence interval.
+const z95 = 1.959964
+
+// Running totals over the trials of a rollout, from the point of view of the
+// Roller of the position rolled out. It is a brd.Analysis.
+type Stats struct {
+	Trials     int
+	Sum        float64           // of each trial's equity
+	SumSquares float64           // of each trial's equity squared
+	Totals     analysis.Outcomes // the sum of each trial's Outcomes
+}
+
+// Records a trial that ended with the given Outcomes and equity.
+func (s *Stats) Add(o analysis.Outcomes, equity float64) {
+	s.Trials++
+	s.Sum += equity
+	s.SumSquares += equity * equity
+	s.Totals.Win += o.Win
+	s.Totals.WinGammon += o.WinGammon
+	s.Totals.WinBackgammon += o.WinBackgammon
+	s.Totals.LoseGammon += o.LoseGammon
+	s.Totals.LoseBackgammon += o.LoseBackgammon
+}
+
+// Adds in the trials of another rollout of the same position.
+func (s *Stats) Merge(o Stats) {
+	s.Trials += o.Trials
+	s.Sum += o.Sum
+	s.SumSquares += o.SumSquares
+	s.Totals.Win += o.Totals.Win
+	s.Totals.WinGammon += o.Totals.WinGammon
+	s.Totals.WinBackgammon += o.Totals.WinBackgammon
+	s.Totals.LoseGammon += o.Totals.LoseGammon
+	s.Totals.LoseBackgammon += o.Totals.LoseBackgammon
+}
+
+// The mean equity per trial.
+func (s Stats) Equity() float64 {
+	if s.Trials == 0 {
+		return 0
+	}
+	return s.Sum / float64(s.Trials)
+}
+
+// The standard error of Equity(), from the sample variance of the trials.
+func (s Stats) StdErr() float64 {
+	if s.Trials < 2 {
+		return 0
+	}
+	n := float64(s.Trials)
+	variance := (s.SumSquares - s.Sum*s.Sum/n) / (n - 1)
+	return math.Sqrt(math.Max(variance, 0) / n)
+}
+
+// The 95% confidence interval for the equity.
+func (s Stats) ConfidenceInterval() (low, high float64) {
+	return s.Equity() - z95*s.StdErr(), s.Equity() + z95*s.StdErr()
+}
+
+// The mean Outcomes per trial, e.g., the fraction of games won.
+func (s Stats) Outcomes() analysis.Outcomes {
+	if s.Trials == 0 {
+		return analysis.Outcomes{}
+	}
+	n := float64(s.Trials)
+	return analysis.Outcomes{
+		Win:            s.Totals.Win / n,
+		WinGammon:      s.Totals.WinGammon / n,
+		WinBackgammon:  s.Totals.WinBackgammon / n,
+		LoseGammon:     s.Totals.LoseGammon / n,
+		LoseBackgammon: s.Totals.LoseBackgammon / n,
+	}
+}
+
+func (s Stats) Summary() string {
+	return fmt.Sprintf("equity %+.3f ± %.3f in %d trials: %v", s.Equity(), s.StdErr(), s.Trials, s.Outcomes().Summary())
+}
@@ -42,8 +42,14 @@ func Board(width int, board *brd.Board, drawer Drawer) {
 	for i := 13; i < 25; i++ {
 		c.makeTriangle(top, i, &board.Pips[i], drawer)
 	}
-	c.makeBorneOff(top, &board.Pips[brd.BorneOffWhitePip], drawer)
-	c.makeBorneOff(bottom, &board.Pips[brd.BorneOffRedPip], drawer)
+	whiteBorneOffColumn := 17
+	if board.Variant == brd.LongNardy {
+		// White's home is [13, 18], the top left quadrant, in Long Nardy. There
+		// is no doubling cube to get in the way over there.
+		whiteBorneOffColumn = 0
+	}
+	c.makeBorneOff(top, whiteBorneOffColumn, &board.Pips[brd.BorneOffWhitePip], drawer)
+	c.makeBorneOff(bottom, 17, &board.Pips[brd.BorneOffRedPip], drawer)
 	c.makeBarBackground(drawer)
 	c.makeBar(top, &board.Pips[brd.BarRedPip], drawer)
 	c.makeBar(bottom, &board.Pips[brd.BarWhitePip], drawer)
@@ -169,10 +175,10 @@ func (c canvas) makeBorder(drawer Drawer) {
 	drawer.Line(0, c.Height-1, c.Width-1, c.Height-1, fmt.Sprintf("stroke-width:%d;stroke:black", borderThickness)) // bottom
 }
 
-func (c canvas) makeBorneOff(where topOrBottom, pt *brd.Point, drawer Drawer) {
+func (c canvas) makeBorneOff(where topOrBottom, col int, pt *brd.Point, drawer Drawer) {
 	// TODO(chandler37): Beautify. Right now you get overlap. If there are more
 	// than a few checkers, write the number instead of showing them all.
-	x := (c.column(17) + c.column(18)) / 2
+	x := (c.column(col) + c.column(col+1)) / 2
 	if where == top {
 		for color, _ := range checkerStyle {
 			for checkerNum := 0; checkerNum < pt.Num(color); checkerNum++ {
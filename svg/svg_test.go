@@ -120,3 +120,46 @@ func TestBoard(t *testing.T) {
 		t.Errorf("x=%v", x)
 	}
 }
+
+type circleDrawer struct {
+	testDrawer
+	Xs map[string][]int
+}
+
+func (d *circleDrawer) Circle(x int, y int, r int, s ...string) {
+	d.Xs[s[0]] = append(d.Xs[s[0]], x)
+}
+
+func TestBoardLongNardy(t *testing.T) {
+	rand.Seed(37)
+	b := brd.NewVariant(brd.LongNardy, true)
+	b.Pips[12].Reset(14, brd.White)
+	b.Pips[brd.BorneOffWhitePip].Reset(1, brd.White)
+	b.Pips[24].Reset(14, brd.Red)
+	b.Pips[brd.BorneOffRedPip].Reset(1, brd.Red)
+	drawer := circleDrawer{Xs: map[string][]int{}}
+	Board(240, b, &drawer)
+	whites, reds := drawer.Xs[checkerStyle[brd.White]], drawer.Xs[checkerStyle[brd.Red]]
+	if len(whites) != 15 || len(reds) != 15 {
+		t.Fatalf("drawer=%v", drawer)
+	}
+	c := canvas{240, 240}
+	// The heads are diagonally opposite, and White bears off on the left.
+	if x := whites[0]; x != (c.column(2)+c.column(3))/2 {
+		t.Errorf("x=%v", x)
+	}
+	if x := whites[14]; x != (c.column(0)+c.column(1))/2 {
+		t.Errorf("x=%v", x)
+	}
+	if x := reds[0]; x != (c.column(15)+c.column(16))/2 {
+		t.Errorf("x=%v", x)
+	}
+	if x := reds[14]; x != (c.column(17)+c.column(18))/2 {
+		t.Errorf("x=%v", x)
+	}
+	for _, a := range drawer.Actions {
+		if a == "CenterRect" {
+			t.Errorf("there is no doubling cube in Long Nardy")
+		}
+	}
+}